@@ -2,6 +2,7 @@ package linguo
 
 import (
 	"container/list"
+	"context"
 	"strconv"
 	"strings"
 )
@@ -21,10 +22,19 @@ func (c *ChartParser) getStartSymbol() string {
 }
 
 func (c *ChartParser) Analyze(s *Sentence) {
+	c.AnalyzeContext(context.Background(), s)
+}
+
+// AnalyzeContext parses s like Analyze, giving up as soon as ctx is done.
+// A cancelled sentence keeps the parse trees built for the previous k-best
+// sequences only.
+func (c *ChartParser) AnalyzeContext(ctx context.Context, s *Sentence) error {
 	for k := 0; k < s.numKBest(); k++ {
 		ch := NewChart(c.gram)
 		ch.loadSentence(s, k)
-		ch.parse()
+		if err := ch.parse(ctx); err != nil {
+			return err
+		}
 		tr := ch.getTree(ch.getSize()-1, 0, "")
 		for w, n := s.Front(), tr.begin(); w != nil && n.pnode != tr.end().pnode; n = n.PlusPlus() {
 			if n.pnode.numChildren() == 0 {
@@ -37,6 +47,7 @@ func (c *ChartParser) Analyze(s *Sentence) {
 		tr.buildNodeIndex(s.sentID)
 		s.setParseTree(tr, k)
 	}
+	return nil
 }
 
 type Edge struct {
@@ -168,10 +179,13 @@ func (c *Chart) loadSentence(s *Sentence, k int) {
 	}
 }
 
-func (c *Chart) parse() {
+func (c *Chart) parse(ctx context.Context) error {
 	var k, i, a int
 	for k = 1; k < c.size; k++ {
 		for i = 0; i < c.size-k; i++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			ce := list.New()
 			for a = 0; a < k; a++ {
 				for ed := c.table[c.index(a, i)].Front(); ed != nil; ed = ed.Next() {
@@ -227,6 +241,7 @@ func (c *Chart) parse() {
 		c.table[c.index(c.size-1, 0)].PushBack(e1)
 	}
 
+	return nil
 }
 
 func (c *Chart) cover(a, b int) *list.List {
@@ -0,0 +1,160 @@
+// Command nertrain trains a linguo.PerceptronNER model from CoNLL-style BIO
+// files.
+//
+//	$ go run cmd/nertrain/main.go -o data/en/ner/perceptron.dat -tag 1 eng.train
+//
+// The model can then be used with NLPOptions.NERModelFilePath. With -nec it
+// trains instead the classifier of the NEC stage on the entities of the
+// corpus, to be listed in the <Model> section of the NEC data file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ruggi/linguo"
+)
+
+func main() {
+	out := flag.String("o", "", "model file to write")
+	iterations := flag.Int("iter", 10, "training iterations")
+	lemmaCol := flag.Int("lemma", -1, "column holding the lemma, -1 if none")
+	tagCol := flag.Int("tag", -1, "column holding the PoS tag, -1 if none")
+	eval := flag.String("eval", "", "CoNLL file to evaluate the model on")
+	nec := flag.Bool("nec", false, "train a NEC classifier instead of a BIO tagger")
+	gaz := flag.String("gaz", "", "gazetteer file used by the NEC classifier")
+	flag.Parse()
+
+	if *out == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: nertrain -o model.dat [options] train.conll...")
+		flag.PrintDefaults()
+		os.Exit(2)
+	}
+
+	var train []linguo.NERSentence
+	for _, file := range flag.Args() {
+		train = append(train, read(file, *lemmaCol, *tagCol)...)
+	}
+	log.Printf("training on %d sentences", len(train))
+
+	if *nec {
+		var gazetteer map[string]string
+		if *gaz != "" {
+			var err error
+			if gazetteer, err = linguo.LoadGazetteer(*gaz); err != nil {
+				log.Fatal(err)
+			}
+		}
+		classifier := linguo.TrainNEC(train, gazetteer, *iterations)
+		if err := classifier.SaveModel(*out); err != nil {
+			log.Fatal(err)
+		}
+		if *eval != "" {
+			fmt.Printf("accuracy %.4f\n", evaluateNEC(classifier, read(*eval, *lemmaCol, *tagCol)))
+		}
+		return
+	}
+
+	ner := linguo.TrainPerceptronNER(train, *iterations)
+	if err := ner.Save(*out); err != nil {
+		log.Fatal(err)
+	}
+
+	if *eval != "" {
+		p, r, f := evaluate(ner, read(*eval, *lemmaCol, *tagCol))
+		fmt.Printf("precision %.4f recall %.4f F1 %.4f\n", p, r, f)
+	}
+}
+
+func read(file string, lemmaCol, tagCol int) []linguo.NERSentence {
+	f, err := os.Open(file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	sentences, err := linguo.ReadCoNLL(f, lemmaCol, tagCol)
+	if err != nil {
+		log.Fatalf("%s: %v", file, err)
+	}
+	return sentences
+}
+
+// evaluate returns the entity level precision, recall and F1 of ner.
+func evaluate(ner *linguo.PerceptronNER, sentences []linguo.NERSentence) (float64, float64, float64) {
+	var correct, guessed, gold float64
+	for _, s := range sentences {
+		labels, _ := ner.Label(s.Tokens)
+		want := spans(s.Labels)
+		got := spans(labels)
+		gold += float64(len(want))
+		guessed += float64(len(got))
+		for span := range got {
+			if want[span] {
+				correct++
+			}
+		}
+	}
+	if guessed == 0 || gold == 0 || correct == 0 {
+		return 0, 0, 0
+	}
+	p, r := correct/guessed, correct/gold
+	return p, r, 2 * p * r / (p + r)
+}
+
+// evaluateNEC returns the share of gold entities given the right class.
+func evaluateNEC(nec *linguo.NEC, sentences []linguo.NERSentence) float64 {
+	var correct, total float64
+	for _, s := range sentences {
+		for i := 0; i < len(s.Labels); i++ {
+			if len(s.Labels[i]) < 2 || s.Labels[i][:2] != "B-" {
+				continue
+			}
+			class := s.Labels[i][2:]
+			j := i + 1
+			for j < len(s.Labels) && s.Labels[j] == "I-"+class {
+				j++
+			}
+			tokens := merge(s.Tokens, i, j)
+			if nec.Classify(tokens, i) == class {
+				correct++
+			}
+			total++
+			i = j - 1
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return correct / total
+}
+
+// merge joins tokens[i:j] into a single multiword token.
+func merge(tokens []linguo.NERToken, i, j int) []linguo.NERToken {
+	forms := make([]string, 0, j-i)
+	for _, t := range tokens[i:j] {
+		forms = append(forms, t.Form)
+	}
+	out := append([]linguo.NERToken{}, tokens[:i]...)
+	out = append(out, linguo.NERToken{Form: strings.Join(forms, "_"), Tag: tokens[i].Tag})
+	return append(out, tokens[j:]...)
+}
+
+func spans(labels []string) map[string]bool {
+	out := make(map[string]bool)
+	for i := 0; i < len(labels); i++ {
+		if len(labels[i]) < 2 || labels[i][:2] != "B-" {
+			continue
+		}
+		j := i + 1
+		for j < len(labels) && labels[j] == "I-"+labels[i][2:] {
+			j++
+		}
+		out[fmt.Sprintf("%d:%d:%s", i, j, labels[i][2:])] = true
+		i = j - 1
+	}
+	return out
+}
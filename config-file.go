@@ -1,6 +1,7 @@
 package linguo
 
 import (
+	"fmt"
 	"io/ioutil"
 	"strings"
 )
@@ -8,6 +9,7 @@ import (
 type ConfigFile struct {
 	lines                       []string
 	sectionsOpen, sectionsClose map[string]int
+	sectionNames                map[int]string
 	section                     int
 	SECTION_NONE                int
 	SECTION_UNKNOWN             int
@@ -17,6 +19,7 @@ type ConfigFile struct {
 	lineNum                     int
 	skipUnknownSections         bool
 	unkName                     string
+	module                      int64
 }
 
 func NewConfigFile(skip bool, comment string) ConfigFile {
@@ -30,6 +33,7 @@ func NewConfigFile(skip bool, comment string) ConfigFile {
 		commentPrefix:       comment,
 		sectionsOpen:        make(map[string]int),
 		sectionsClose:       make(map[string]int),
+		sectionNames:        make(map[int]string),
 	}
 }
 
@@ -48,6 +52,7 @@ func (c *ConfigFile) IsComment(s string) bool {
 func (c *ConfigFile) AddSection(key string, section int) {
 	c.sectionsOpen["<"+key+">"] = section
 	c.sectionsClose["</"+key+">"] = section
+	c.sectionNames[section] = key
 }
 
 func (c *ConfigFile) PrintSections() {
@@ -56,18 +61,18 @@ func (c *ConfigFile) PrintSections() {
 	}
 }
 
-func (c *ConfigFile) Open(filename string) bool {
+func (c *ConfigFile) Open(filename string) error {
 	c.filename = filename
 	c.section = c.SECTION_NONE
 	fileString, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return false
+		return openError(c.module, filename, err)
 	}
 	lines := strings.Split(string(fileString), "\n")
 	c.lines = make([]string, len(lines))
 	copy(c.lines, lines)
 	c.lineNum = -1
-	return true
+	return nil
 }
 
 func (c *ConfigFile) GetSection() int {
@@ -78,6 +83,32 @@ func (c *ConfigFile) GetLineNum() int {
 	return c.lineNum
 }
 
+func (c *ConfigFile) GetSectionName() string {
+	if c.section == c.SECTION_UNKNOWN {
+		return c.unkName
+	}
+	return c.sectionNames[c.section]
+}
+
+// Errorf returns a ConfigError pointing at the current line and section.
+func (c *ConfigFile) Errorf(format string, args ...interface{}) error {
+	return &ConfigError{
+		Module:  c.module,
+		File:    c.filename,
+		Line:    c.lineNum + 1,
+		Section: c.GetSectionName(),
+		Msg:     fmt.Sprintf(format, args...),
+	}
+}
+
+// CheckFields returns an error unless the current line has at least n fields.
+func (c *ConfigFile) CheckFields(items []string, n int) error {
+	if len(items) < n || (n > 0 && items[0] == "") {
+		return c.Errorf("expected at least %d fields, found %d", n, len(items))
+	}
+	return nil
+}
+
 func (c *ConfigFile) AtSectionStart() bool {
 	return c.sectionStart
 }
@@ -2,6 +2,7 @@ package linguo
 
 import (
 	"container/list"
+	"context"
 	"io/ioutil"
 	"math"
 	"regexp"
@@ -62,7 +63,7 @@ func IntPairsArray2List(a IntPairsArray) *list.List {
 	return out
 }
 
-func NewCSRKB(kbFile string, nit int, thr float64, damp float64) *CSRKB {
+func NewCSRKB(kbFile string, nit int, thr float64, damp float64) (*CSRKB, error) {
 	ukb := CSRKB{
 		vertexIndex:   make(map[string]int),
 		maxIterations: nit,
@@ -77,15 +78,18 @@ func NewCSRKB(kbFile string, nit int, thr float64, damp float64) *CSRKB {
 
 	fileString, err := ioutil.ReadFile(kbFile)
 	if err != nil {
-		panic(err)
+		return nil, openError(MOD_UKB, kbFile, err)
 	}
 
 	lines := strings.Split(string(fileString), "\n")
-	for _, line := range lines {
+	for n, line := range lines {
 		if line == "" {
 			continue
 		}
 		items := Split(line, " ")
+		if len(items) < 2 {
+			return nil, newConfigError(MOD_UKB, kbFile, n+1, "expected a pair of synsets")
+		}
 
 		syn1 = items[0]
 		syn2 = items[1]
@@ -101,7 +105,7 @@ func NewCSRKB(kbFile string, nit int, thr float64, damp float64) *CSRKB {
 	}
 
 	ukb.fillCSRTables(ukb.numVertices, rels)
-	return &ukb
+	return &ukb, nil
 }
 
 func (ukb *CSRKB) fillCSRTables(nv int, rels *list.List) {
@@ -148,7 +152,7 @@ func (ukb *CSRKB) getVertex(s string) int {
 	}
 }
 
-func (ukb *CSRKB) pageRank(pv []float64) {
+func (ukb *CSRKB) pageRank(ctx context.Context, pv []float64) error {
 	var ranks [2][]float64
 	CURRENT := 0
 	NEXT := 1
@@ -160,6 +164,9 @@ func (ukb *CSRKB) pageRank(pv []float64) {
 	nit := 0
 	change := ukb.threshold
 	for nit < ukb.maxIterations && change >= ukb.threshold {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
 		change = 0
 
 		for v := 0; v < ukb.numVertices; v++ {
@@ -180,6 +187,7 @@ func (ukb *CSRKB) pageRank(pv []float64) {
 	}
 
 	ArrayFloatSwap(pv, ranks[CURRENT])
+	return nil
 }
 
 type UKB struct {
@@ -187,7 +195,7 @@ type UKB struct {
 	RE_wnpos *regexp.Regexp
 }
 
-func NewUKB(wsdFile string) *UKB {
+func NewUKB(wsdFile string) (*UKB, error) {
 	ukb := UKB{
 		RE_wnpos: regexp.MustCompile(RE_WNP),
 	}
@@ -203,9 +211,10 @@ func NewUKB(wsdFile string) *UKB {
 	cfg.AddSection("RelationFile", UKB_RELATION_FILE)
 	cfg.AddSection("RE_Wordnet_PoS", UKB_REX_WNPOS)
 	cfg.AddSection("PageRankParameters", UKB_PR_PARAMS)
+	cfg.module = MOD_UKB
 
-	if !cfg.Open(wsdFile) {
-		panic("Error loading file " + wsdFile)
+	if err := cfg.Open(wsdFile); err != nil {
+		return nil, err
 	}
 
 	line := ""
@@ -227,11 +236,18 @@ func NewUKB(wsdFile string) *UKB {
 			}
 		case UKB_REX_WNPOS:
 			{
-				ukb.RE_wnpos = regexp.MustCompile(line)
+				re, err := regexp.Compile(line)
+				if err != nil {
+					return nil, cfg.Errorf("invalid regular expression: %v", err)
+				}
+				ukb.RE_wnpos = re
 				break
 			}
 		case UKB_PR_PARAMS:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				key := items[0]
 				if key == "Threshold" {
 					thr, _ = strconv.ParseFloat(items[1], 64)
@@ -248,12 +264,16 @@ func NewUKB(wsdFile string) *UKB {
 	}
 
 	if relFile == "" {
-		panic("No relation file provided in UKB configuration file " + wsdFile)
+		return nil, newConfigError(MOD_UKB, wsdFile, 0, "no relation file provided in UKB configuration file")
 	}
 
-	ukb.wn = NewCSRKB(relFile, nit, thr, damp)
+	wn, err := NewCSRKB(relFile, nit, thr, damp)
+	if err != nil {
+		return nil, err
+	}
+	ukb.wn = wn
 
-	return &ukb
+	return &ukb, nil
 }
 
 func (ukb *UKB) initSynsetVector(sentences []*Sentence, pv []float64) {
@@ -335,8 +355,17 @@ func (ukb *UKB) extractRanksToSentences(sentences []*Sentence, pv []float64) {
 }
 
 func (ukb *UKB) Analyze(sentences []*Sentence) {
+	ukb.AnalyzeContext(context.Background(), sentences)
+}
+
+// AnalyzeContext ranks word senses like Analyze, stopping between PageRank
+// iterations once ctx is done. Senses are left unranked when cancelled.
+func (ukb *UKB) AnalyzeContext(ctx context.Context, sentences []*Sentence) error {
 	pv := make([]float64, ukb.wn.size())
 	ukb.initSynsetVector(sentences, pv)
-	ukb.wn.pageRank(pv)
+	if err := ukb.wn.pageRank(ctx, pv); err != nil {
+		return err
+	}
 	ukb.extractRanksToSentences(sentences, pv)
+	return nil
 }
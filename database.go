@@ -31,7 +31,7 @@ func NewDatabase(t int) *Database {
 	return &db
 }
 
-func NewDatabaseFromFile(dbFile string) *Database {
+func NewDatabaseFromFile(dbFile string) (*Database, error) {
 	db := Database{
 		DBType: DB_MAP,
 		dbmap:  make(map[string]string),
@@ -40,7 +40,7 @@ func NewDatabaseFromFile(dbFile string) *Database {
 	if dbFile != "" {
 		filestr, err := ioutil.ReadFile(dbFile)
 		if err != nil {
-			return nil
+			return nil, openError(0, dbFile, err)
 		}
 		lines := strings.Split(string(filestr), "\n")
 		if lines[0] == "DB_PREFTREE" {
@@ -51,6 +51,9 @@ func NewDatabaseFromFile(dbFile string) *Database {
 			line := lines[i]
 			if line != "" {
 				pos := strings.Index(line, " ")
+				if pos < 0 {
+					return nil, newConfigError(0, dbFile, i+1, "expected a key followed by data")
+				}
 				key := line[0:pos]
 				data := line[pos+1:]
 				db.addDatabase(key, data)
@@ -58,7 +61,7 @@ func NewDatabaseFromFile(dbFile string) *Database {
 		}
 	}
 
-	return &db
+	return &db, nil
 }
 
 func (db *Database) addDatabase(key string, data string) {
@@ -0,0 +1,610 @@
+package linguo
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DATES_TAG = "W"
+
+// Kinds of the items of a date pattern.
+const (
+	DATES_IT_WORD = iota
+	DATES_IT_CLASS
+	DATES_IT_GROUP
+	DATES_IT_NOT
+)
+
+// dateItem is an item of a date pattern: a word, a class of words written
+// $CLASS, a group of alternatives, optional when written in brackets, or
+// !word, which matches nothing but fails when the previous word is word.
+type dateItem struct {
+	kind     int
+	text     string
+	alts     [][]dateItem
+	optional bool
+}
+
+// Classes of words that date patterns can use. The ones with a word list in
+// datesLang take their value from it, the others are numbers or regexps.
+var datesClasses = map[string]bool{
+	"WEEKDAY": true, "MONTH": true, "DAY": true, "YEAR": true, "NUMDATE": true,
+	"HOUR": true, "MINUTES": true, "MINWORD": true, "CLOCK": true, "AMPM": true,
+	"DAYPART": true, "NAMEDTIME": true, "BEFORE": true, "DAYREL": true, "DAYSHIFT": true,
+	"RELDIR": true, "UNIT": true, "COUNT": true, "AGO": true, "FOR": true,
+}
+
+// datesLang holds the words and patterns of a language. Patterns are
+// written as items separated by spaces, with %MACRO replaced by the text of
+// the macro. monthFirst tells how to read numeric dates as 3/4/2017, and
+// capitalized whether the names of months and weekdays are, which tells "May"
+// from "may".
+type datesLang struct {
+	words       map[string]map[string]string
+	macros      map[string]string
+	patterns    []string
+	monthFirst  bool
+	capitalized bool
+}
+
+func newDatesLang(lang string) *datesLang {
+	switch lang {
+	case "en":
+		return &datesLang{
+			words: map[string]map[string]string{
+				"WEEKDAY": {"monday": "L", "tuesday": "M", "wednesday": "X", "thursday": "J", "friday": "V", "saturday": "S", "sunday": "G"},
+				"MONTH": {"january": "1", "february": "2", "march": "3", "april": "4", "may": "5", "june": "6",
+					"july": "7", "august": "8", "september": "9", "october": "10", "november": "11", "december": "12",
+					"jan.": "1", "feb.": "2", "mar.": "3", "apr.": "4", "jun.": "6", "jul.": "7", "aug.": "8",
+					"sep.": "9", "sept.": "9", "oct.": "10", "nov.": "11", "dec.": "12"},
+				"AMPM":      {"am": "am", "pm": "pm", "a.m.": "am", "p.m.": "pm"},
+				"DAYPART":   {"morning": "MO am", "afternoon": "AF pm", "evening": "EV pm", "night": "NI pm"},
+				"MINUTES":   {"half": "30", "quarter": "15"},
+				"NAMEDTIME": {"noon": "12", "midday": "12", "midnight": "0"},
+				"BEFORE":    {"to": "", "before": ""},
+				"DAYREL":    {"yesterday": "-1", "today": "0", "tomorrow": "1", "tonight": "0 NI"},
+				"RELDIR":    {"next": "1", "last": "-1", "this": "0", "coming": "1"},
+				"UNIT": {"day": "D", "days": "D", "week": "W", "weeks": "W", "weekend": "WE", "month": "M", "months": "M",
+					"year": "Y", "years": "Y", "hour": "H", "hours": "H", "minute": "MI", "minutes": "MI"},
+				"COUNT": {"a": "1", "an": "1"},
+				"AGO":   {"ago": ""},
+				"FOR":   {"for": ""},
+			},
+			macros: map[string]string{
+				"DATE": "( [ $WEEKDAY [ , ] [ the ] ] ( $MONTH [ the ] $DAY [ [ , ] $YEAR ] | $DAY [ of ] $MONTH [ [ , ] $YEAR ] | $NUMDATE ) " +
+					"| $MONTH [ , ] $YEAR | $WEEKDAY | %REL )",
+				"REL": "( $DAYREL [ $DAYPART ] | $RELDIR ( $WEEKDAY [ $DAYPART ] | $UNIT | $DAYPART ) | $WEEKDAY $DAYPART " +
+					"| $COUNT $UNIT ( $AGO | later | from now ) | in $COUNT $UNIT | $FOR $COUNT $UNIT )",
+				"AMPM": "[ $AMPM | in the $DAYPART ]",
+				"TIME": "( $CLOCK %AMPM | $HOUR ( $AMPM | in the $DAYPART | ( o'clock | o ' clock ) %AMPM ) " +
+					"| [ a ] $MINWORD ( past | after ) $HOUR %AMPM | [ a ] $MINWORD $BEFORE $HOUR %AMPM | $NAMEDTIME )",
+			},
+			patterns: []string{
+				"%DATE [ [ , ] [ at ] %TIME ]",
+				"%TIME [ [ , ] [ on ] %DATE ]",
+			},
+			monthFirst:  true,
+			capitalized: true,
+		}
+
+	case "es":
+		return &datesLang{
+			words: map[string]map[string]string{
+				"WEEKDAY": {"lunes": "L", "martes": "M", "miércoles": "X", "miercoles": "X", "jueves": "J", "viernes": "V", "sábado": "S", "sabado": "S", "domingo": "G"},
+				"MONTH": {"enero": "1", "febrero": "2", "marzo": "3", "abril": "4", "mayo": "5", "junio": "6",
+					"julio": "7", "agosto": "8", "septiembre": "9", "setiembre": "9", "octubre": "10", "noviembre": "11", "diciembre": "12"},
+				"HOUR":      {"una": "1"},
+				"AMPM":      {"am": "am", "pm": "pm", "a.m.": "am", "p.m.": "pm"},
+				"DAYPART":   {"mañana": "MO am", "madrugada": "NI am", "tarde": "AF pm", "noche": "NI pm"},
+				"MINUTES":   {"media": "30", "cuarto": "15"},
+				"NAMEDTIME": {"mediodía": "12", "mediodia": "12", "medianoche": "0"},
+				"BEFORE":    {"menos": ""},
+				"DAYREL":    {"ayer": "-1", "hoy": "0", "mañana": "1", "anteayer": "-2", "antier": "-2", "anoche": "-1 NI"},
+				"DAYSHIFT":  {"pasado": "1"},
+				"RELDIR": {"próximo": "1", "próxima": "1", "proximo": "1", "proxima": "1", "pasado": "-1", "pasada": "-1",
+					"último": "-1", "última": "-1", "ultimo": "-1", "ultima": "-1", "este": "0", "esta": "0", "viene": "1"},
+				"UNIT": {"día": "D", "días": "D", "dia": "D", "dias": "D", "semana": "W", "semanas": "W", "mes": "M", "meses": "M",
+					"año": "Y", "años": "Y", "hora": "H", "horas": "H", "minuto": "MI", "minutos": "MI"},
+				"COUNT": {"un": "1", "una": "1"},
+				"AGO":   {"hace": ""},
+				"FOR":   {"durante": ""},
+			},
+			// "las cinco" alone is too often not a time, so it needs the
+			// preposition or the minutes, and "mañana" is not "tomorrow" in
+			// "por la mañana"
+			macros: map[string]string{
+				"DATE": "( [ $WEEKDAY [ , ] ] ( $DAY de $MONTH [ ( de | del ) $YEAR ] | $NUMDATE ) | $MONTH ( de | del ) $YEAR | $WEEKDAY [ $DAY ] | %REL )",
+				"REL": "( !la !cada !una !esa !aquella [ $DAYSHIFT ] $DAYREL [ ( por | en ) la $DAYPART ] | $RELDIR ( $WEEKDAY | $UNIT | $DAYPART ) " +
+					"| ( $WEEKDAY | $UNIT ) ( $RELDIR | que $RELDIR ) | $WEEKDAY ( por | en ) la $DAYPART | $AGO $COUNT $UNIT | dentro de $COUNT $UNIT | $FOR $COUNT $UNIT )",
+				"AMPM":    "[ de la $DAYPART | $AMPM ]",
+				"MINUTES": "( ( y | con ) $MINUTES | $BEFORE $MINUTES | en punto )",
+				"TIME": "( ( la | las ) $HOUR ( %MINUTES %AMPM | de la $DAYPART ) | a ( la | las ) $HOUR [ %MINUTES ] %AMPM " +
+					"| [ a ] [ la | las ] $CLOCK [ h | horas ] %AMPM | $NAMEDTIME )",
+			},
+			patterns: []string{
+				"%DATE [ [ , ] [ a ] %TIME ]",
+				"%TIME [ [ , ] del %DATE ]",
+			},
+		}
+	}
+	return nil
+}
+
+// dateValue is what a date expression tells, -1 or "" when it does not.
+// Expressions relative to the time they are said in move it by shift units
+// (D, W, WE, M, Y, H or MI), or to the weekday in the direction dir, and
+// durations last count units.
+type dateValue struct {
+	weekday  string
+	day      int
+	month    int
+	year     int
+	hour     int
+	minute   int
+	ampm     string
+	daypart  string
+	before   bool
+	dayrel   bool
+	days     int
+	hasDir   bool
+	dir      int
+	count    int
+	unit     string
+	shift    int
+	ago      bool
+	duration bool
+}
+
+func newDateValue() dateValue {
+	return dateValue{day: -1, month: -1, year: -1, hour: -1, minute: -1, count: -1}
+}
+
+// valid tells whether the day exists in the month, and whether the parts of a
+// relative expression fit together: "next week" but not "next day", "two
+// weeks" only with a direction or as a duration.
+func (this dateValue) valid() bool {
+	switch {
+	case this.day > 0 && this.month > 0 && this.day > monthDays(this.month, this.year):
+		return false
+	case this.unit != "" && this.count < 0 && !this.hasDir:
+		return false
+	case this.count >= 0 && this.unit == "":
+		return false
+	case this.hasDir && this.count < 0 && (this.unit == "D" || this.unit == "H" || this.unit == "MI"):
+		return false
+	case this.hasDir && this.unit == "" && this.weekday == "" && this.daypart == "":
+		return false
+	}
+	return true
+}
+
+// monthDays returns the number of days of month in year, 29 for February
+// when the year is not known.
+func monthDays(month int, year int) int {
+	if year < 0 {
+		year = 2000
+	}
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func (this dateValue) relative() bool {
+	return this.unit != "" || this.hasDir
+}
+
+// lemma writes the value as FreeLing does: [weekday:day/month/year:hour.minute:am/pm]
+func (this dateValue) lemma() string {
+	field := func(n int) string {
+		if n < 0 {
+			return "??"
+		}
+		return strconv.Itoa(n)
+	}
+	weekday := If(this.weekday == "", "??", this.weekday).(string)
+	ampm := If(this.ampm == "", "??", this.ampm).(string)
+	minute := "??"
+	if this.minute >= 0 {
+		minute = fmt.Sprintf("%02d", this.minute)
+	}
+	// relative expressions add how they move, or their duration
+	rel := ""
+	if this.duration {
+		rel = ":" + this.period()
+	} else if this.unit != "" {
+		rel = fmt.Sprintf(":%+d%s", this.shift, this.unit)
+	} else if this.hasDir {
+		rel = fmt.Sprintf(":%+d", this.dir)
+	}
+	return "[" + weekday + ":" + field(this.day) + "/" + field(this.month) + "/" + field(this.year) + ":" + field(this.hour) + "." + minute + ":" + ampm + rel + "]"
+}
+
+// finish resolves the minutes counted before the hour and sets them to zero
+// when only the hour is known, and works out the shift of relative
+// expressions.
+func (this *dateValue) finish() {
+	switch {
+	case this.dayrel:
+		this.unit, this.shift = "D", this.days
+	case this.unit != "":
+		this.shift = If(this.count >= 0, this.count, this.dir).(int)
+		if this.ago {
+			this.shift = -this.shift
+		}
+	case this.hasDir && this.weekday == "":
+		// "this morning", "last night"
+		this.unit, this.shift = "D", this.dir
+	}
+
+	if this.before && this.hour >= 0 && this.minute > 0 {
+		this.hour--
+		if this.hour == 0 {
+			this.hour = 12
+		}
+		this.minute = 60 - this.minute
+	}
+	if this.hour >= 0 && this.minute < 0 {
+		this.minute = 0
+	}
+}
+
+// Dates recognizes date and time expressions ("March 3rd, 2017", "half past
+// five", "el lunes 3 de marzo a las cinco y media") and joins them in a
+// multiword tagged W, with the date as lemma in FreeLing format, as in
+// [??:3/3/2017:??.??:??]. Relative expressions ("next Tuesday", "two weeks
+// ago", "ayer por la tarde") and durations ("for three days") add how they
+// move from the time they are said in, as in [??:??/??/??:??.??:??:-2W]; the
+// timex stage resolves them. It is meant to run after the numbers module,
+// whose analyses give the value of spelled numbers.
+type Dates struct {
+	lang     *datesLang
+	patterns []dateItem
+	numdate  *regexp.Regexp
+	clock    *regexp.Regexp
+	digits   *regexp.Regexp
+}
+
+// NewDates creates the dates module for lang, which can be English or
+// Spanish.
+func NewDates(lang string) (*Dates, error) {
+	this := Dates{
+		lang:    newDatesLang(lang),
+		numdate: regexp.MustCompile(`^(\d{1,4})([/-])(\d{1,2})([/-])(\d{1,4})$`),
+		clock:   regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm|a\.m\.|p\.m\.|h)?$`),
+		digits:  regexp.MustCompile(`^(\d{1,4})(st|nd|rd|th|º|ª)?$`),
+	}
+	if this.lang == nil {
+		return nil, newConfigError(MOD_DATES, "", 0, "no dates module for language '%s'", lang)
+	}
+
+	macro := regexp.MustCompile(`%[A-Z]+`)
+	for _, p := range this.lang.patterns {
+		for n := 0; macro.MatchString(p); n++ {
+			if n > 10 {
+				return nil, newConfigError(MOD_DATES, "", 0, "recursive macro in date pattern '%s'", p)
+			}
+			p = macro.ReplaceAllStringFunc(p, func(m string) string { return this.lang.macros[m[1:]] })
+		}
+		alts, _, err := parseDateItems(strings.Fields(p), 0, "")
+		if err != nil {
+			return nil, newConfigError(MOD_DATES, "", 0, "invalid date pattern '%s': %v", p, err)
+		}
+		this.patterns = append(this.patterns, dateItem{kind: DATES_IT_GROUP, alts: alts})
+	}
+
+	TRACE(1, "Module created successfully", MOD_DATES)
+
+	return &this, nil
+}
+
+// parseDateItems reads the alternatives of a group from tokens[pos:] up to
+// its closing bracket, returning them with the position after it.
+func parseDateItems(tokens []string, pos int, closing string) ([][]dateItem, int, error) {
+	alts := [][]dateItem{nil}
+	for pos < len(tokens) {
+		t := tokens[pos]
+		pos++
+		last := len(alts) - 1
+		switch t {
+		case "|":
+			alts = append(alts, nil)
+		case "[", "(":
+			sub, p, err := parseDateItems(tokens, pos, If(t == "[", "]", ")").(string))
+			if err != nil {
+				return nil, 0, err
+			}
+			pos = p
+			alts[last] = append(alts[last], dateItem{kind: DATES_IT_GROUP, alts: sub, optional: t == "["})
+		case "]", ")":
+			if t != closing {
+				return nil, 0, fmt.Errorf("unexpected '%s'", t)
+			}
+			return alts, pos, nil
+		default:
+			if strings.HasPrefix(t, "$") {
+				if !datesClasses[t[1:]] {
+					return nil, 0, fmt.Errorf("unknown class '%s'", t)
+				}
+				alts[last] = append(alts[last], dateItem{kind: DATES_IT_CLASS, text: t[1:]})
+			} else if strings.HasPrefix(t, "!") && len(t) > 1 {
+				alts[last] = append(alts[last], dateItem{kind: DATES_IT_NOT, text: t[1:]})
+			} else {
+				alts[last] = append(alts[last], dateItem{kind: DATES_IT_WORD, text: t})
+			}
+		}
+	}
+	if closing != "" {
+		return nil, 0, fmt.Errorf("missing '%s'", closing)
+	}
+	return alts, pos, nil
+}
+
+// match goes through the words from j matching items, and calls k with the
+// position after the match and its value for every way they match.
+func (this *Dates) match(words []*list.Element, items []dateItem, j int, v dateValue, k func(int, dateValue)) {
+	if len(items) == 0 {
+		k(j, v)
+		return
+	}
+
+	it := items[0]
+	switch it.kind {
+	case DATES_IT_GROUP:
+		if it.optional {
+			this.match(words, items[1:], j, v, k)
+		}
+		for _, alt := range it.alts {
+			this.match(words, alt, j, v, func(j int, v dateValue) {
+				this.match(words, items[1:], j, v, k)
+			})
+		}
+	case DATES_IT_WORD:
+		if j < len(words) && words[j].Value.(*Word).getLCForm() == it.text {
+			this.match(words, items[1:], j+1, v, k)
+		}
+	case DATES_IT_CLASS:
+		if j < len(words) && !words[j].Value.(*Word).isLocked() && this.matchClass(it.text, words[j].Value.(*Word), &v) {
+			this.match(words, items[1:], j+1, v, k)
+		}
+	case DATES_IT_NOT:
+		if j == 0 || words[j-1].Value.(*Word).getLCForm() != it.text {
+			this.match(words, items[1:], j, v, k)
+		}
+	}
+}
+
+// matchClass tells whether w belongs to class, adding what it tells to v.
+func (this *Dates) matchClass(class string, w *Word, v *dateValue) bool {
+	form := w.getLCForm()
+	value, inList := this.lang.words[class][form]
+
+	set := func(field *int, n int, min int, max int) bool {
+		if *field >= 0 || n < min || n > max {
+			return false
+		}
+		*field = n
+		return true
+	}
+
+	if (class == "WEEKDAY" || class == "MONTH") && this.lang.capitalized && !IsCapitalized(w.getForm()) {
+		return false
+	}
+
+	switch class {
+	case "WEEKDAY":
+		if !inList || v.weekday != "" {
+			return false
+		}
+		v.weekday = value
+		return true
+	case "MONTH":
+		n, _ := strconv.Atoi(value)
+		return inList && set(&v.month, n, 1, 12)
+	case "DAY":
+		n, ok := this.number(w, true)
+		return ok && set(&v.day, n, 1, 31)
+	case "YEAR":
+		n, ok := this.number(w, false)
+		return ok && (len(form) == 4 || !strings.ContainsAny(form, "0123456789")) && set(&v.year, n, 1000, 2999)
+	case "HOUR":
+		n, ok := this.number(w, false)
+		if inList {
+			n, _ = strconv.Atoi(value)
+			ok = true
+		}
+		return ok && set(&v.hour, n, 0, 24)
+	case "MINUTES", "MINWORD":
+		n, ok := this.number(w, false)
+		if class == "MINWORD" && strings.ContainsAny(form, "0123456789") {
+			ok = false
+		}
+		if words := this.lang.words["MINUTES"]; words[form] != "" {
+			n, _ = strconv.Atoi(words[form])
+			ok = true
+		}
+		return ok && set(&v.minute, n, 0, 59)
+	case "AMPM":
+		if !inList || v.ampm != "" {
+			return false
+		}
+		v.ampm = value
+		return true
+	case "DAYPART":
+		if !inList || v.ampm != "" || v.daypart != "" {
+			return false
+		}
+		fields := strings.Fields(value)
+		v.daypart, v.ampm = fields[0], fields[1]
+		return true
+	case "DAYREL":
+		if !inList || v.dayrel {
+			return false
+		}
+		fields := strings.Fields(value)
+		n, _ := strconv.Atoi(fields[0])
+		v.dayrel = true
+		v.days += n
+		if len(fields) > 1 {
+			v.daypart = fields[1]
+		}
+		return true
+	case "DAYSHIFT":
+		n, _ := strconv.Atoi(value)
+		v.days += n
+		return inList
+	case "RELDIR":
+		if !inList || v.hasDir {
+			return false
+		}
+		v.hasDir = true
+		v.dir, _ = strconv.Atoi(value)
+		return true
+	case "UNIT":
+		if !inList || v.unit != "" {
+			return false
+		}
+		v.unit = value
+		return true
+	case "COUNT":
+		n, ok := this.number(w, false)
+		if inList {
+			n, _ = strconv.Atoi(value)
+			ok = true
+		}
+		return ok && set(&v.count, n, 0, 9999)
+	case "AGO":
+		v.ago = true
+		return inList
+	case "FOR":
+		v.duration = true
+		return inList
+	case "NAMEDTIME":
+		n, _ := strconv.Atoi(value)
+		return inList && set(&v.hour, n, 0, 24) && set(&v.minute, 0, 0, 59)
+	case "BEFORE":
+		if !inList || v.before {
+			return false
+		}
+		v.before = true
+		return true
+	case "CLOCK":
+		m := this.clock.FindStringSubmatch(form)
+		if m == nil || m[2] == "" && m[3] == "" {
+			return false
+		}
+		h, _ := strconv.Atoi(m[1])
+		if !set(&v.hour, h, 0, 24) {
+			return false
+		}
+		if m[2] != "" {
+			n, _ := strconv.Atoi(m[2])
+			if !set(&v.minute, n, 0, 59) {
+				return false
+			}
+		}
+		if m[3] != "" && m[3] != "h" && v.ampm == "" {
+			v.ampm = strings.Replace(m[3], ".", "", -1)
+		}
+		return true
+	case "NUMDATE":
+		return this.numericDate(form, v)
+	}
+	return false
+}
+
+// number returns the value of a word holding an integer, as given by the
+// numbers module or written with digits. Ordinals are only taken when
+// ordinal is set.
+func (this *Dates) number(w *Word, ordinal bool) (int, bool) {
+	for a := w.Front(); a != nil; a = a.Next() {
+		tag := a.Value.(*Analysis).getTag()
+		if tag == NUMBERS_CARDINAL_TAG || ordinal && tag == NUMBERS_ORDINAL_TAG {
+			if n, err := strconv.Atoi(a.Value.(*Analysis).getLemma()); err == nil {
+				return n, true
+			}
+		}
+	}
+	if m := this.digits.FindStringSubmatch(w.getLCForm()); m != nil && (ordinal || m[2] == "") {
+		n, _ := strconv.Atoi(m[1])
+		return n, true
+	}
+	return 0, false
+}
+
+// numericDate reads dates as 2017-03-03 or 3/3/2017, in the order of the
+// language unless it gives no valid date.
+func (this *Dates) numericDate(form string, v *dateValue) bool {
+	m := this.numdate.FindStringSubmatch(form)
+	if m == nil || m[2] != m[4] || v.day >= 0 || v.month >= 0 || v.year >= 0 {
+		return false
+	}
+	a, _ := strconv.Atoi(m[1])
+	b, _ := strconv.Atoi(m[3])
+	c, _ := strconv.Atoi(m[5])
+
+	var day, month, year int
+	if len(m[1]) == 4 {
+		year, month, day = a, b, c
+	} else if len(m[5]) == 4 || len(m[5]) == 2 {
+		year = c
+		day, month = a, b
+		if this.lang.monthFirst {
+			day, month = b, a
+		}
+		if month > 12 {
+			day, month = month, day
+		}
+	} else {
+		return false
+	}
+	if month < 1 || month > 12 || day < 1 || day > monthDays(month, year) {
+		return false
+	}
+	v.day, v.month, v.year = day, month, year
+	return true
+}
+
+func (this *Dates) Analyze(se *Sentence) {
+	this.analyze(se)
+}
+
+func (this *Dates) analyze(se *Sentence) {
+	words := make([]*list.Element, 0, se.Len())
+	for i := se.Front(); i != nil; i = i.Next() {
+		words = append(words, i)
+	}
+
+	found := false
+	for i := 0; i < len(words); i++ {
+		if words[i].Value.(*Word).isLocked() {
+			continue
+		}
+		end, value := i, dateValue{}
+		for _, p := range this.patterns {
+			this.match(words, []dateItem{p}, i, newDateValue(), func(j int, v dateValue) {
+				if j > end && v.valid() {
+					end, value = j, v
+				}
+			})
+		}
+		if end == i {
+			continue
+		}
+
+		value.finish()
+		w := words[i].Value.(*Word)
+		if end-1 > i {
+			w = se.joinWords(words[i], words[end-1])
+			found = true
+		}
+		w.setAnalysis(NewAnalysis(value.lemma(), DATES_TAG))
+		w.date = &value
+		TRACE(3, "Found date "+w.getForm()+" "+value.lemma(), MOD_DATES)
+		i = end - 1
+	}
+	if found {
+		se.rebuildWordIndex()
+	}
+}
@@ -0,0 +1,87 @@
+package linguo
+
+import (
+	"strings"
+	"testing"
+)
+
+// newDatesSentence runs the numbers and dates modules of lang on the words of
+// text.
+func newDatesSentence(t *testing.T, lang, text string) *Sentence {
+	t.Helper()
+	numb, err := NewNumbers(lang, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dates, err := NewDates(lang)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSentence()
+	for _, f := range strings.Fields(text) {
+		s.PushBack(NewWordFromLemma(f))
+	}
+	numb.Analyze(s)
+	dates.Analyze(s)
+	return s
+}
+
+// datesString writes the words of newDatesSentence as form/lemma for the
+// dates, or form alone for the other words.
+func datesString(t *testing.T, lang, text string) string {
+	t.Helper()
+	var out []string
+	s := newDatesSentence(t, lang, text)
+	for _, w := range s.Words() {
+		if a := w.Selected(); a != nil && a.getTag() == DATES_TAG {
+			out = append(out, w.getForm()+"/"+a.getLemma())
+		} else {
+			out = append(out, w.getForm())
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestDates(t *testing.T) {
+	for _, tt := range []struct {
+		lang, text, want string
+	}{
+		{"en", "March 3rd , 2017", "March_3rd_,_2017/[??:3/3/2017:??.??:??]"},
+		{"en", "at half past five", "at half_past_five/[??:??/??/??:5.30:??]"},
+		{"en", "on 3/4/2017", "on 3/4/2017/[??:4/3/2017:??.??:??]"},
+		{"en", "on 2017-03-04", "on 2017-03-04/[??:4/3/2017:??.??:??]"},
+		{"en", "next Tuesday", "next_Tuesday/[M:??/??/??:??.??:??:+1]"},
+		{"es", "el 3 de marzo de 2017", "el 3_de_marzo_de_2017/[??:3/3/2017:??.??:??]"},
+		{"es", "a las cinco y media", "a_las_cinco_y_media/[??:??/??/??:5.30:??]"},
+		{"es", "el 3/4/2017", "el 3/4/2017/[??:3/4/2017:??.??:??]"},
+	} {
+		if got := datesString(t, tt.lang, tt.text); got != tt.want {
+			t.Errorf("%s: %q: got %q, want %q", tt.lang, tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestDatesDaysOfMonth checks that only the days a month has make a date.
+func TestDatesDaysOfMonth(t *testing.T) {
+	for _, tt := range []struct {
+		lang, text, want string
+	}{
+		{"en", "on 2/29/2016", "on 2/29/2016/[??:29/2/2016:??.??:??]"},
+		{"en", "on 2/29/2017", "on 2/29/2017"},
+		{"en", "on 2000-02-29", "on 2000-02-29/[??:29/2/2000:??.??:??]"},
+		{"en", "on 1900-02-29", "on 1900-02-29"},
+		{"en", "on 4/31/2017", "on 4/31/2017"},
+		{"en", "on 31/02/2017", "on 31/02/2017"},
+		// the year is left out when the day is not in it
+		{"en", "February 29 , 2017", "February_29/[??:29/2/??:??.??:??] , 2017"},
+		{"en", "April 31st", "April 31st"},
+		{"es", "el 31/02/2017", "el 31/02/2017"},
+		{"es", "el 29/2/2016", "el 29/2/2016/[??:29/2/2016:??.??:??]"},
+		{"es", "el 31 de abril", "el 31 de abril"},
+		{"es", "el 30 de febrero de 2016", "el 30 de febrero_de_2016/[??:??/2/2016:??.??:??]"},
+	} {
+		if got := datesString(t, tt.lang, tt.text); got != tt.want {
+			t.Errorf("%s: %q: got %q, want %q", tt.lang, tt.text, got, tt.want)
+		}
+	}
+}
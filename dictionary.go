@@ -2,6 +2,7 @@ package linguo
 
 import (
 	"container/list"
+	"fmt"
 	"math"
 	"strings"
 
@@ -35,8 +36,11 @@ type Dictionary struct {
 	posPrefs   map[string]string
 }
 
-func NewDictionary(Lang string, dicFile string, sufFile string, compFile string, invDic bool, retok bool) *Dictionary {
-	dict := Dictionary{}
+func NewDictionary(Lang string, dicFile string, sufFile string, compFile string, invDic bool, retok bool) (*Dictionary, error) {
+	dict := Dictionary{
+		lemmaPrefs: make(map[string]string),
+		posPrefs:   make(map[string]string),
+	}
 
 	dict.InverseDic = invDic
 	dict.RetokenizeContractions = retok
@@ -44,7 +48,11 @@ func NewDictionary(Lang string, dicFile string, sufFile string, compFile string,
 	dict.suf = nil
 
 	if sufFile != "" {
-		dict.suf = NewAffixes(sufFile)
+		suf, err := NewAffixes(sufFile)
+		if err != nil {
+			return nil, err
+		}
+		dict.suf = suf
 	}
 
 	dict.AffixAnalysis = (dict.suf != nil)
@@ -61,14 +69,22 @@ func NewDictionary(Lang string, dicFile string, sufFile string, compFile string,
 	cfg.AddSection("LemmaPreferences", DICTIONARY_LEMMA_PREF)
 	cfg.AddSection("PosPreferences", DICTIONARY_POS_PREF)
 	cfg.AddSection("Entries", DICTIONARY_ENTRIES)
+	cfg.module = MOD_DICTIONARY
 
-	if !cfg.Open(dicFile) {
-		panic("Error opening file " + dicFile)
+	if err := cfg.Open(dicFile); err != nil {
+		return nil, err
 	}
 
 	dict.morfodb = nil
 	dict.inverdb = nil
 
+	// contractions are checked once every entry is loaded
+	type contraction struct {
+		line             int
+		form, lemma, tag string
+	}
+	var contractions []contraction
+
 	line := ""
 
 	for cfg.GetContentLine(&line) {
@@ -82,7 +98,7 @@ func NewDictionary(Lang string, dicFile string, sufFile string, compFile string,
 				} else if line == "DB_MAP" {
 					tpe = DB_MAP
 				} else {
-					panic("Invalid IndexType '" + line + "' specified in dictionary file " + dicFile)
+					return nil, cfg.Errorf("invalid IndexType '%s'", line)
 				}
 
 				dict.morfodb = NewDatabase(tpe)
@@ -93,6 +109,9 @@ func NewDictionary(Lang string, dicFile string, sufFile string, compFile string,
 			}
 		case DICTIONARY_LEMMA_PREF:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				lem1 := items[0]
 				lem2 := items[1]
 				_, exists := dict.lemmaPrefs[lem1]
@@ -103,6 +122,9 @@ func NewDictionary(Lang string, dicFile string, sufFile string, compFile string,
 			}
 		case DICTIONARY_POS_PREF:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				pos1 := items[0]
 				pos2 := items[1]
 				_, exists := dict.posPrefs[pos1]
@@ -114,21 +136,29 @@ func NewDictionary(Lang string, dicFile string, sufFile string, compFile string,
 		case DICTIONARY_ENTRIES:
 			{
 				if dict.morfodb == nil {
-					panic("No IndexType specified in dictionary file " + dicFile)
+					return nil, cfg.Errorf("no IndexType specified")
 				}
 
 				pos := strings.Index(line, " ")
+				if pos < 1 {
+					return nil, cfg.Errorf("invalid format, expected a form followed by lemma-tag pairs")
+				}
 				key := line[0:pos]
 				data := line[pos+1:]
 
-				if key == "" {
-					panic("Invalid format. Unexpected blank line in " + dicFile)
-				}
-
 				lems := list.New()
 
 				if !dict.ParseDictEntry(data, lems) {
-					panic("Invalid pair lemma-tag in dictionary line " + key + " " + data)
+					return nil, cfg.Errorf("invalid pair lemma-tag in dictionary line %s %s", key, data)
+				}
+
+				for p := lems.Front(); p != nil; p = p.Next() {
+					lemma := p.Value.(Pair).first.(string)
+					for t := p.Value.(Pair).second.(*list.List).Front(); t != nil; t = t.Next() {
+						if strings.Contains(lemma, "+") && strings.Contains(t.Value.(string), "+") {
+							contractions = append(contractions, contraction{cfg.GetLineNum() + 1, key, lemma, t.Value.(string)})
+						}
+					}
 				}
 
 				data = dict.CompactData(lems)
@@ -149,7 +179,42 @@ func NewDictionary(Lang string, dicFile string, sufFile string, compFile string,
 		}
 	}
 
-	return &dict
+	for _, c := range contractions {
+		if msg := dict.checkContraction(c.lemma, c.tag); msg != "" {
+			err := newConfigError(MOD_DICTIONARY, dicFile, c.line, "invalid contraction '%s': %s", c.form, msg)
+			err.Section = "Entries"
+			return nil, err
+		}
+	}
+
+	return &dict, nil
+}
+
+// checkContraction tells what is wrong with the lemma and tag of a
+// contraction, such as "de+el SP+DA/PD": every component must be in the
+// dictionary with a tag starting with one of its alternatives, or with any tag
+// for "*". It returns "" when the contraction is valid.
+func (d *Dictionary) checkContraction(lemma, tag string) string {
+	lemmas, tags := strings.Split(lemma, "+"), strings.Split(tag, "+")
+	if len(lemmas) != len(tags) {
+		return fmt.Sprintf("%d lemmas for %d tags", len(lemmas), len(tags))
+	}
+	for k, cl := range lemmas {
+		la := list.New()
+		d.SearchForm(cl, la)
+		found := false
+		for a := la.Front(); a != nil && !found; a = a.Next() {
+			for _, t := range Split(tags[k], "/") {
+				if strings.HasPrefix(a.Value.(*Analysis).getTag(), t) || t == "*" {
+					found = true
+				}
+			}
+		}
+		if !found {
+			return fmt.Sprintf("no entry for '%s' with tag %s", cl, tags[k])
+		}
+	}
+	return ""
 }
 
 func (d *Dictionary) less(s1 string, s2 string, pref map[string]string) bool {
@@ -310,8 +375,8 @@ func (d *Dictionary) CheckContracted(form string, lem string, tag string, lw *li
 	la := list.New()
 	contr := false
 
-	pl := strings.Index(lem, "+")
-	pt := strings.Index(tag, "+")
+	pl := RuneIndex(lem, "+")
+	pt := RuneIndex(tag, "+")
 
 	for pl > -1 && pt > -1 {
 		contr = true
@@ -336,11 +401,14 @@ func (d *Dictionary) CheckContracted(form string, lem string, tag string, lw *li
 		lw.PushBack(c)
 
 		if c.getNAnalysis() == 0 {
-			panic("Tag not found for contraction component. Check dictionary entries for '" + form + "' and '" + cl + "'")
+			// NewDictionary rejects such entries, keep the word whole
+			WARNING("Tag not found for contraction component. Check dictionary entries for '"+form+"' and '"+cl+"'", MOD_DICTIONARY)
+			lw.Init()
+			return false
 		}
 
-		pl = strings.Index(lem, "+")
-		pt = strings.Index(tag, "+")
+		pl = RuneIndex(lem, "+")
+		pt = RuneIndex(tag, "+")
 	}
 
 	if contr {
@@ -352,11 +420,7 @@ func (d *Dictionary) CheckContracted(form string, lem string, tag string, lw *li
 		la = list.New()
 		d.SearchForm(cl, la)
 
-		if caps == 2 {
-			cl = strings.ToUpper(cl)
-		} else if caps == 1 && lw.Len() == 0 {
-			cl = strings.Title(cl)
-		}
+		cl = Capitalize(cl, caps, lw.Len() == 0)
 		c := NewWordFromLemma(cl)
 		for a := la.Front(); a != nil; a = a.Next() {
 			for _, t := range ct {
@@ -368,7 +432,9 @@ func (d *Dictionary) CheckContracted(form string, lem string, tag string, lw *li
 
 		lw.PushBack(c)
 		if c.getNAnalysis() == 0 {
-			panic("Tag not found for contraction component. Check dictionary entries for '" + form + "' and '" + cl + "'")
+			WARNING("Tag not found for contraction component. Check dictionary entries for '"+form+"' and '"+cl+"'", MOD_DICTIONARY)
+			lw.Init()
+			return false
 		}
 	}
 
@@ -441,16 +507,29 @@ func (d *Dictionary) AnnotateWord(w *Word, lw *list.List, override bool) bool {
 }
 
 func (d *Dictionary) Analyze(se *Sentence) {
+	d.analyze(se, false)
+}
+
+// analyze searches the words of se in the dictionary. When noRetok is set
+// contractions are left as a single word even if RetokenizeContractions is.
+func (d *Dictionary) analyze(se *Sentence, noRetok bool) {
 	contr := false
 
 	for pos := se.Front(); pos != nil; pos = pos.Next() {
+		if pos.Value.(*Word).isLocked() {
+			continue
+		}
 		if pos.Value.(*Word).getNAnalysis() == 0 || (pos.Value.(*Word).getNAnalysis() > 0 && string(pos.Value.(*Word).getTag(0)[0]) == "Z") {
 
 			lw := list.New()
 
-			if d.AnnotateWord(pos.Value.(*Word), lw, false) {
+			if d.AnnotateWord(pos.Value.(*Word), lw, noRetok) {
 				st := pos.Value.(*Word).getSpanStart()
 				fin := pos.Value.(*Word).getSpanFinish()
+				rst, rfin := pos.Value.(*Word).RuneSpan()
+				// rune spans follow the byte ones when the form is all ASCII,
+				// otherwise every part gets the span of the whole word
+				ascii := fin-st == rfin-rst
 
 				step := (float64(fin) - float64(st) + 1.0) / float64(lw.Len())
 				step = math.Max(1, step)
@@ -462,6 +541,11 @@ func (d *Dictionary) Analyze(se *Sentence) {
 				for i = lw.Front(); i != nil; i = i.Next() {
 					f := If(n == lw.Len(), fin, st+int(ln)).(int)
 					i.Value.(*Word).setSpan(st, f)
+					if ascii {
+						i.Value.(*Word).setRuneSpan(st-(fin-rfin), f-(fin-rfin))
+					} else {
+						i.Value.(*Word).setRuneSpan(rst, rfin)
+					}
 					i.Value.(*Word).user = pos.Value.(*Word).user
 
 					pos = se.InsertBefore(i.Value.(*Word), pos)
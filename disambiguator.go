@@ -48,7 +48,7 @@ type Disambiguator struct {
 	binds map[string]*set.Set
 }
 
-func NewDisambiguator(disFile string) *Disambiguator {
+func NewDisambiguator(disFile string) (*Disambiguator, error) {
 	disambiguator := Disambiguator{
 		wnids: make(map[string]*Synset),
 		binds: make(map[string]*set.Set),
@@ -56,11 +56,11 @@ func NewDisambiguator(disFile string) *Disambiguator {
 
 	fileString, err := ioutil.ReadFile(disFile)
 	if err != nil {
-		panic("Error loading file " + disFile)
+		return nil, openError(MOD_DISAMBIGUATOR, disFile, err)
 	}
 
 	lines := strings.Split(string(fileString), "\n")
-	for _, line := range lines {
+	for n, line := range lines {
 		if line == "" {
 			continue
 		}
@@ -79,6 +79,9 @@ func NewDisambiguator(disFile string) *Disambiguator {
 		switch scope {
 		case DOCUMENT_SCOPE, SENTENCE_SCOPE, ND_SCOPE:
 			{
+				if len(items) < 8 || len(items[5]) < 1 {
+					return nil, newConfigError(MOD_DISAMBIGUATOR, disFile, n+1, "expected 8 tab-separated fields, found %d", len(items))
+				}
 				lemma := items[1]
 				wnid := items[2]
 				pos, _ := strconv.ParseFloat(items[3], 64)
@@ -92,6 +95,9 @@ func NewDisambiguator(disFile string) *Disambiguator {
 			}
 		case SENTENCE_BIND:
 			{
+				if len(items) < 2 || len(items[1]) < 1 {
+					return nil, newConfigError(MOD_DISAMBIGUATOR, disFile, n+1, "expected a bind key")
+				}
 				key := items[1][1:]
 				for i := 2; i < len(items); i++ {
 					if disambiguator.binds[key] == nil {
@@ -103,7 +109,7 @@ func NewDisambiguator(disFile string) *Disambiguator {
 		}
 	}
 
-	return &disambiguator
+	return &disambiguator, nil
 }
 
 func (d *Disambiguator) Analyze(ss *list.List) {
@@ -1,44 +1,322 @@
 package linguo
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
 	"sync"
+	"time"
 )
 
+var (
+	ErrLanguageNotLoaded = errors.New("language not loaded")
+	ErrUnknownLanguage   = errors.New("cannot identify language")
+	ErrClosed            = errors.New("engine closed")
+)
+
+// Engine holds one NLPEngine per loaded language. Language independent data
+// (common/punct.dat, common/knowledge.dat) is loaded once and shared by all of
+// them.
 type Engine struct {
 	semaphore *sync.Mutex
-	NLP       *NLPEngine
-	Ready     bool
+	// NLP is the engine of the first language loaded.
+	NLP   *NLPEngine
+	Ready bool
+
+	lock           sync.RWMutex
+	engines        map[string]*NLPEngine
+	options        map[string]*NLPOptions
+	punts          map[string]*Punts
+	disambiguators map[string]*Disambiguator
+	identifier     *LanguageIdentifier
 }
 
 func NewEngine() *Engine {
 	return &Engine{
-		semaphore: new(sync.Mutex),
-		Ready:     false,
+		semaphore:      new(sync.Mutex),
+		Ready:          false,
+		engines:        make(map[string]*NLPEngine),
+		options:        make(map[string]*NLPOptions),
+		punts:          make(map[string]*Punts),
+		disambiguators: make(map[string]*Disambiguator),
 	}
 }
 
-func (e *Engine) InitNLP(path, lang string) {
+// InitNLP loads the language data found under path for lang. Calling it again
+// with another language adds that language to the engine; a language already
+// loaded is left as is. If any of the data files is missing or malformed the
+// language is not added and the error, usually a *ConfigError, is returned.
+// The quantities (<lang>/quantities.dat) and the user map (<lang>/usermap.dat)
+// are optional and only loaded when present.
+func (e *Engine) InitNLP(path, lang string) error {
 	e.semaphore.Lock()
 	defer e.semaphore.Unlock()
 
-	if e.Ready {
-		return
+	if e.Get(lang) != nil {
+		return nil
 	}
 
-	options := e.makeOptions(path, lang)
-	nlpEngine := NewNLPEngine(options)
+	options, err := e.makeOptions(path, lang)
+	if err != nil {
+		return err
+	}
+	nlpEngine, err := NewNLPEngine(options)
+	if err != nil {
+		return err
+	}
 
-	e.NLP = nlpEngine
+	e.lock.Lock()
+	e.engines[lang] = nlpEngine
+	e.options[lang] = options
+	if e.NLP == nil {
+		e.NLP = nlpEngine
+	}
 	e.Ready = true
+	e.lock.Unlock()
+	return nil
+}
+
+// Reload reads the data files of lang again and swaps the new pipeline in once
+// it is fully built. Calls already running finish on the old one, which is
+// then closed; if loading fails the old pipeline is kept and the error
+// returned. While Reload waits for those calls the engine can be used,
+// reloaded and closed as usual. The common files shared between languages are
+// not reloaded.
+//
+// A SentenceScanner is not a single call: one created on the old pipeline
+// stops with ErrClosed at its next Scan once the old pipeline is closed.
+// Streams that must survive a reload should be restarted from the position
+// of the last sentence read, on the pipeline returned by Get.
+//
+// Reload replaces NLP when it points to lang, so code running concurrently
+// with a reload should use Get or Workflow instead of that field.
+func (e *Engine) Reload(lang string) error {
+	old, err := e.swap(lang)
+	if err != nil {
+		return err
+	}
+	// the other languages can be loaded, reloaded or closed while the calls
+	// running on old finish
+	return old.Close()
+}
+
+// swap builds a new pipeline for lang and puts it in place of the loaded one,
+// which it returns.
+func (e *Engine) swap(lang string) (*NLPEngine, error) {
+	e.semaphore.Lock()
+	defer e.semaphore.Unlock()
+
+	options, ok := e.options[lang]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrLanguageNotLoaded, lang)
+	}
+	nlpEngine, err := NewNLPEngine(options)
+	if err != nil {
+		return nil, err
+	}
+
+	e.lock.Lock()
+	old := e.engines[lang]
+	if e.NLP == old {
+		e.NLP = nlpEngine
+	}
+	e.engines[lang] = nlpEngine
+	e.lock.Unlock()
+	return old, nil
+}
+
+// Close closes every loaded language, waiting for the running calls to
+// finish. The engine can be initialized again afterwards.
+func (e *Engine) Close() error {
+	e.semaphore.Lock()
+	e.lock.Lock()
+	engines := e.engines
+	e.engines = make(map[string]*NLPEngine)
+	e.options = make(map[string]*NLPOptions)
+	e.punts = make(map[string]*Punts)
+	e.disambiguators = make(map[string]*Disambiguator)
+	e.NLP = nil
+	e.Ready = false
+	e.lock.Unlock()
+	e.semaphore.Unlock()
+
+	var firstErr error
+	for _, nlp := range engines {
+		if err := nlp.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Watch checks the modification time of the data files of every loaded
+// language each interval and reloads the languages whose files changed, until
+// ctx is done. Reload errors are passed to onError, which may be nil; the
+// language is retried on the next change. As with Reload, the scanners open
+// on a reloaded language stop with ErrClosed.
+func (e *Engine) Watch(ctx context.Context, interval time.Duration, onError func(lang string, err error)) {
+	seen := make(map[string]time.Time)
+	changed := func(lang string) bool {
+		e.semaphore.Lock()
+		options := e.options[lang]
+		e.semaphore.Unlock()
+		if options == nil {
+			// closed since Languages was called
+			return false
+		}
+
+		modified := false
+		for _, file := range options.files() {
+			info, err := os.Stat(file)
+			if err != nil {
+				continue
+			}
+			if last, ok := seen[file]; ok && !info.ModTime().Equal(last) {
+				modified = true
+			}
+			seen[file] = info.ModTime()
+		}
+		return modified
+	}
+	for _, lang := range e.Languages() {
+		changed(lang)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, lang := range e.Languages() {
+				if !changed(lang) {
+					continue
+				}
+				if err := e.Reload(lang); err != nil && onError != nil {
+					onError(lang, err)
+				}
+			}
+		}
+	}
+}
+
+// Get returns the engine loaded for lang, or nil.
+func (e *Engine) Get(lang string) *NLPEngine {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	return e.engines[lang]
+}
+
+// Languages returns the loaded languages, sorted.
+func (e *Engine) Languages() []string {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	langs := make([]string, 0, len(e.engines))
+	for lang := range e.engines {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Workflow analyses input with the pipeline loaded for lang.
+func (e *Engine) Workflow(lang, input string) (Result, error) {
+	return e.WorkflowContext(context.Background(), lang, input)
+}
+
+// WorkflowContext analyses input with the pipeline loaded for lang. A call
+// that has started is not affected by a concurrent Reload: it finishes on the
+// pipeline it started with.
+func (e *Engine) WorkflowContext(ctx context.Context, lang, input string) (Result, error) {
+	nlp, err := e.acquire(lang)
+	if err != nil {
+		return Result{}, err
+	}
+	defer nlp.release()
+	return nlp.workflow(ctx, input, nil)
+}
+
+// acquire returns the pipeline of lang, held as NLPEngine.acquire does. It is
+// taken while the map is locked, so that Reload cannot close it in between.
+func (e *Engine) acquire(lang string) (*NLPEngine, error) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+	nlp := e.engines[lang]
+	if nlp == nil {
+		return nil, fmt.Errorf("%w: %q", ErrLanguageNotLoaded, lang)
+	}
+	if !nlp.acquire() {
+		return nil, ErrClosed
+	}
+	return nlp, nil
+}
+
+// InitLanguageIdentifier loads the n-gram profiles listed in
+// common/lang_ident/ident.dat under path.
+func (e *Engine) InitLanguageIdentifier(path string) error {
+	identifier, err := NewLanguageIdentifier(path + "/common/lang_ident/ident.dat")
+	if err != nil {
+		return err
+	}
+	e.lock.Lock()
+	e.identifier = identifier
+	e.lock.Unlock()
+	return nil
+}
+
+// Identify ranks the loaded languages by how likely they are to be the
+// language of text. It returns nil when no identifier was loaded.
+func (e *Engine) Identify(text string) []LanguageGuess {
+	e.lock.RLock()
+	identifier := e.identifier
+	e.lock.RUnlock()
+	langs := e.Languages()
+	if identifier == nil || len(langs) == 0 {
+		return nil
+	}
+	return identifier.Rank(text, langs...)
+}
+
+// IdentifyWorkflow identifies the language of input and analyses it with the
+// matching pipeline, returning the language used.
+func (e *Engine) IdentifyWorkflow(ctx context.Context, input string) (string, Result, error) {
+	guesses := e.Identify(input)
+	if len(guesses) == 0 {
+		return "", Result{}, ErrUnknownLanguage
+	}
+	lang := guesses[0].Lang
+	result, err := e.WorkflowContext(ctx, lang, input)
+	return lang, result, err
 }
 
-func (e *Engine) makeOptions(path, lang string) *NLPOptions {
+func (e *Engine) makeOptions(path, lang string) (*NLPOptions, error) {
+	punts, err := e.sharedPunts(path + "/common/punct.dat")
+	if err != nil {
+		return nil, err
+	}
+	dsb, err := e.sharedDisambiguator(path + "/common/knowledge.dat")
+	if err != nil {
+		return nil, err
+	}
+
 	macoOptions := NewMacoOptions(path, lang).
-		PunctuationFilePath("/common/punct.dat").
+		WithPunctuation(punts).
 		DictionaryFilePath("/" + lang + "/dicc.src").
 		LocutionsFilePath("/" + lang + "/locucions-extended.dat").
 		NPdataFilePath("/" + lang + "/np.dat").
 		ProbabilityFilePath("/" + lang + "/probabilitats.dat")
+	macoOptions.SetNumbersDetection(numbersVocabulary(lang))
+	macoOptions.SetDatesDetection(lang == "en" || lang == "es")
+	// optional data, used when the language has it
+	if fileExists(path + "/" + lang + "/quantities.dat") {
+		macoOptions.QuantitiesFilePath("/" + lang + "/quantities.dat")
+	}
+	if fileExists(path + "/" + lang + "/usermap.dat") {
+		macoOptions.UserMapFilePath("/" + lang + "/usermap.dat")
+	}
 
 	return NewNLPOptions(path, lang).
 		TokenizerFilePath("/tokenizer.dat").
@@ -47,6 +325,35 @@ func (e *Engine) makeOptions(path, lang string) *NLPOptions {
 		ShallowParserFilePath("/chunker/grammar-chunk.dat").
 		SenseFilePath("/senses.dat").
 		UKBFilePath("/ukb.dat").
-		DisambiguatorFilePath("/common/knowledge.dat").
-		WithMorfoOptions(macoOptions)
+		WithDisambiguator(dsb).
+		WithMorfoOptions(macoOptions), nil
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
+
+func (e *Engine) sharedPunts(file string) (*Punts, error) {
+	if p, ok := e.punts[file]; ok {
+		return p, nil
+	}
+	p, err := NewPunts(file)
+	if err != nil {
+		return nil, err
+	}
+	e.punts[file] = p
+	return p, nil
+}
+
+func (e *Engine) sharedDisambiguator(file string) (*Disambiguator, error) {
+	if d, ok := e.disambiguators[file]; ok {
+		return d, nil
+	}
+	d, err := NewDisambiguator(file)
+	if err != nil {
+		return nil, err
+	}
+	e.disambiguators[file] = d
+	return d, nil
 }
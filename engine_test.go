@@ -0,0 +1,171 @@
+package linguo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLanguages returns an Engine holding the testdata English pipeline,
+// as InitNLP would.
+func newTestLanguages(t *testing.T) *Engine {
+	t.Helper()
+	o := NewNLPOptions("testdata", "en").
+		TokenizerFilePath("/tokenizer.dat").
+		SplitterFilePath("/splitter.dat").
+		TaggerFilePath("/tagger.dat").
+		WithMorfoOptions(newTestMacoOptions())
+	nlp, err := NewNLPEngine(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine()
+	e.engines["en"] = nlp
+	e.options["en"] = o
+	e.NLP = nlp
+	e.Ready = true
+	return e
+}
+
+// TestWatchClose closes the engine while Watch is polling it.
+func TestWatchClose(t *testing.T) {
+	for n := 0; n < 20; n++ {
+		e := newTestLanguages(t)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			e.Watch(ctx, time.Microsecond, nil)
+			close(done)
+		}()
+		time.Sleep(time.Millisecond)
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+		cancel()
+		<-done
+	}
+}
+
+func TestReloadStopsScanners(t *testing.T) {
+	e := newTestLanguages(t)
+	defer e.Close()
+
+	s := e.Get("en").NewSentenceScanner(context.Background(), strings.NewReader("The robot was created. It is from the episode."))
+	if !s.Scan() {
+		t.Fatalf("first sentence: %v", s.Err())
+	}
+	if err := e.Reload("en"); err != nil {
+		t.Fatal(err)
+	}
+	if s.Scan() || !errors.Is(s.Err(), ErrClosed) {
+		t.Errorf("scanner on the reloaded engine: got %v, want ErrClosed", s.Err())
+	}
+	if _, err := e.Workflow("en", "The robot was created."); err != nil {
+		t.Errorf("new engine: %v", err)
+	}
+}
+
+// TestReloadWorkflow reloads the engine while Workflow runs on it: no call may
+// see the engine it picked closed under it.
+func TestReloadWorkflow(t *testing.T) {
+	e := newTestLanguages(t)
+	defer e.Close()
+
+	done := make(chan struct{})
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			for {
+				select {
+				case <-done:
+					errs <- nil
+					return
+				default:
+				}
+				if _, err := e.Workflow("en", "The robot was created."); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for n := 0; n < 10; n++ {
+		if err := e.Reload("en"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+// TestReloadWaiting checks that a Reload waiting for a call on the old engine
+// does not hold up the engine meanwhile.
+func TestReloadWaiting(t *testing.T) {
+	e := newTestLanguages(t)
+	defer e.Close()
+
+	old, err := e.acquire("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan error)
+	go func() { reloaded <- e.Reload("en") }()
+	for e.Get("en") == old {
+		time.Sleep(time.Millisecond)
+	}
+
+	again := make(chan error)
+	go func() { again <- e.Reload("en") }()
+	select {
+	case err := <-again:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reload waits for the running calls of another Reload")
+	}
+	if _, err := e.Workflow("en", "The robot was created."); err != nil {
+		t.Errorf("new engine: %v", err)
+	}
+
+	select {
+	case err := <-reloaded:
+		t.Fatalf("Reload returned before the running call finished: %v", err)
+	default:
+	}
+	old.release()
+	if err := <-reloaded; err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestMakeOptionsOptionalFiles checks that the quantities and the user map
+// are used when the language has them.
+func TestMakeOptionsOptionalFiles(t *testing.T) {
+	e := NewEngine()
+	o, err := e.makeOptions("testdata", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := o.MorfoOptions.QuantitiesFile, "testdata/en/quantities.dat"; got != want {
+		t.Errorf("en: quantities file %q, want %q", got, want)
+	}
+	if got, want := o.MorfoOptions.UserMapFile, "testdata/en/usermap.dat"; got != want {
+		t.Errorf("en: user map file %q, want %q", got, want)
+	}
+
+	o, err = e.makeOptions("testdata", "xx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.MorfoOptions.QuantitiesFile != "" || o.MorfoOptions.UserMapFile != "" {
+		t.Errorf("xx: got quantities %q and user map %q, want none", o.MorfoOptions.QuantitiesFile, o.MorfoOptions.UserMapFile)
+	}
+}
@@ -0,0 +1,196 @@
+package linguo
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ruggi/linguo/models"
+)
+
+// EntityExtractor finds the named entities of a whole document. It is run by
+// NLPEngine once every stage has completed. Implementations must be safe for
+// concurrent use.
+type EntityExtractor interface {
+	Process(body string) []*models.Entity
+	Release()
+}
+
+// SentenceEntityExtractor is an EntityExtractor that works on the analysed
+// sentences instead of the raw text. NLPEngine calls ProcessSentences instead
+// of Process when the extractor implements it.
+type SentenceEntityExtractor interface {
+	EntityExtractor
+	ProcessSentences(sentences []*Sentence) []*models.Entity
+}
+
+// NoEntityExtractor is an EntityExtractor that never finds any entity. It is
+// the default when linguo is built without MITIE support.
+type NoEntityExtractor struct{}
+
+func (NoEntityExtractor) Process(body string) []*models.Entity { return nil }
+func (NoEntityExtractor) Release()                             {}
+
+// Sources of the entity mentions returned in Result.Entities.
+const (
+	ENTITY_SOURCE_NP         = "np"
+	ENTITY_SOURCE_GAZETTEER  = "gazetteer"
+	ENTITY_SOURCE_MITIE      = "mitie"
+	ENTITY_SOURCE_PERCEPTRON = "perceptron"
+	ENTITY_SOURCE_EXTRACTOR  = "extractor"
+)
+
+// extractEntities runs the entity extractor and merges its mentions with the
+// proper nouns found by the NP module into a single list sorted by offset.
+// Mentions with the same span are merged, the extractor class winning over
+// the NEC one.
+func (e *NLPEngine) extractEntities(input string, sentences []*Sentence) []*models.Entity {
+	var found []*models.Entity
+	if x, ok := e.extractor.(SentenceEntityExtractor); ok {
+		found = x.ProcessSentences(sentences)
+	} else {
+		found = e.extractor.Process(input)
+	}
+	locateEntities(input, found)
+
+	merged := make([]*models.Entity, 0, len(found))
+	bySpan := make(map[[2]int]*models.Entity)
+	add := func(m *models.Entity) {
+		key := [2]int{m.Start, m.End}
+		if m.End == 0 {
+			// not found in the input, keep it apart
+			merged = append(merged, m)
+			return
+		}
+		if prev, ok := bySpan[key]; ok {
+			if prev.Model == "" {
+				prev.Model = m.Model
+			}
+			if m.Score > prev.Score {
+				prev.Score = m.Score
+			}
+			for _, s := range m.Sources {
+				prev.AddSource(s)
+			}
+			return
+		}
+		bySpan[key] = m
+		merged = append(merged, m)
+	}
+
+	for _, m := range found {
+		if len(m.Sources) == 0 {
+			m.AddSource(ENTITY_SOURCE_EXTRACTOR)
+		}
+		add(m)
+	}
+	for _, m := range e.npEntities(sentences) {
+		add(m)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Start < merged[j].Start })
+	runeSpans(input, merged)
+	alignEntities(sentences, merged)
+	return merged
+}
+
+// npEntities returns a mention for every proper noun in the sentences.
+func (e *NLPEngine) npEntities(sentences []*Sentence) []*models.Entity {
+	var entities []*models.Entity
+	for _, s := range sentences {
+		for _, w := range s.Words() {
+			a := w.Selected()
+			if a == nil || !strings.HasPrefix(a.getTag(), "NP") {
+				continue
+			}
+			m := models.NewEntity(w.neClass, a.getProb(), strings.Replace(w.getForm(), "_", " ", -1))
+			m.SetSpan(w.getSpanStart(), w.getSpanFinish())
+			m.AddSource(ENTITY_SOURCE_NP)
+			if e.nec != nil && e.nec.inGazetteer(w.getForm()) {
+				m.AddSource(ENTITY_SOURCE_GAZETTEER)
+			}
+			entities = append(entities, m)
+		}
+	}
+	return entities
+}
+
+// locateEntities gives offsets to the mentions that have none by searching
+// their value in the input, each one after the previous mention of the same
+// value.
+func locateEntities(input string, entities []*models.Entity) {
+	next := make(map[string]int)
+	for _, m := range entities {
+		if m.End > 0 || m.Value == "" {
+			continue
+		}
+		from := next[m.Value]
+		i := strings.Index(input[from:], m.Value)
+		if i < 0 {
+			continue
+		}
+		m.SetSpan(from+i, from+i+len(m.Value))
+		next[m.Value] = m.End
+	}
+}
+
+// runeSpans sets the rune offsets of the mentions, which must be sorted by
+// Start, from their byte offsets.
+func runeSpans(input string, entities []*models.Entity) {
+	b, r := 0, 0
+	for _, m := range entities {
+		if m.End == 0 || m.End > len(input) {
+			continue
+		}
+		r += utf8.RuneCountInString(input[b:m.Start])
+		b = m.Start
+		m.SetRuneSpan(r, r+utf8.RuneCountInString(input[m.Start:m.End]))
+	}
+}
+
+// alignEntities sets the sentence and token range of every mention from its
+// offsets. A mention spanning two sentences is cut at the end of the first.
+func alignEntities(sentences []*Sentence, entities []*models.Entity) {
+	for _, m := range entities {
+		if m.End == 0 {
+			continue
+		}
+	sentences:
+		for n, s := range sentences {
+			for i, w := range s.Words() {
+				if w.getSpanFinish() <= m.Start {
+					continue
+				}
+				if w.getSpanStart() >= m.End {
+					break sentences
+				}
+				if m.Sentence < 0 {
+					m.Sentence, m.TokenStart = n, i
+				}
+				if m.Sentence == n {
+					m.TokenEnd = i + 1
+				}
+			}
+		}
+	}
+}
+
+// unknownEntities counts the proper nouns that neither the extractor nor a
+// gazetteer confirmed.
+func unknownEntities(entities []*models.Entity) []*models.UnknownEntity {
+	var unknown []*models.UnknownEntity
+	index := make(map[string]*models.UnknownEntity)
+	for _, m := range entities {
+		if len(m.Sources) != 1 || m.Sources[0] != ENTITY_SOURCE_NP {
+			continue
+		}
+		if ue, ok := index[m.Value]; ok {
+			ue.Frequency++
+			continue
+		}
+		ue := models.NewUnknownEntity(m.Value, 1)
+		index[m.Value] = ue
+		unknown = append(unknown, ue)
+	}
+	return unknown
+}
@@ -0,0 +1,96 @@
+package linguo
+
+import (
+	"fmt"
+	"strings"
+)
+
+var moduleNames = map[int64]string{
+	MOD_CONFIG:         "config",
+	MOD_TAG_SET:        "tagset",
+	MOD_TOKENIZER:      "tokenizer",
+	MOD_SPLITTER:       "splitter",
+	MOD_ACCENT_DEFAULT: "accents",
+	MOD_ACCENT_ES:      "accents",
+	MOD_AFFIX:          "affixes",
+	MOD_PROBABILITY:    "probability",
+	MOD_DICTIONARY:     "dictionary",
+	MOD_PUNTS:          "punctuation",
+	MOD_HMM:            "hmm",
+	MOD_LOCUTIONS:      "locutions",
+	MOD_LANGUAGE:       "language",
+	MOD_NER:            "ner",
+	MOD_GRAMMAR:        "grammar",
+	MOD_CHART:          "chart",
+	MOD_SENSES:         "senses",
+	MOD_SEMDB:          "semdb",
+	MOD_UKB:            "ukb",
+	MOD_DISAMBIGUATOR:  "disambiguator",
+	MOD_MITIE:          "mitie",
+	MOD_NUMBERS:        "numbers",
+	MOD_DATES:          "dates",
+	MOD_QUANTITIES:     "quantities",
+	MOD_USERMAP:        "usermap",
+	MOD_NLP:            "nlp",
+}
+
+// ConfigError is returned by the module constructors when a data file cannot
+// be opened or contains an entry that cannot be parsed. Line is 1-based and is
+// zero when the error does not refer to a specific line.
+type ConfigError struct {
+	Module  int64
+	File    string
+	Line    int
+	Section string
+	Msg     string
+	Err     error
+}
+
+func (e *ConfigError) Error() string {
+	parts := make([]string, 0, 4)
+	if name, ok := moduleNames[e.Module]; ok {
+		parts = append(parts, name)
+	}
+	loc := e.File
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, e.Line)
+	}
+	if loc != "" {
+		parts = append(parts, loc)
+	}
+	if e.Section != "" {
+		parts = append(parts, "<"+e.Section+">")
+	}
+	msg := e.Msg
+	if e.Err != nil {
+		if msg == "" {
+			msg = e.Err.Error()
+		} else {
+			msg += ": " + e.Err.Error()
+		}
+	}
+	parts = append(parts, msg)
+	return strings.Join(parts, ": ")
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+func newConfigError(module int64, file string, line int, format string, args ...interface{}) *ConfigError {
+	return &ConfigError{
+		Module: module,
+		File:   file,
+		Line:   line,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func openError(module int64, file string, err error) *ConfigError {
+	return &ConfigError{
+		Module: module,
+		File:   file,
+		Msg:    "error opening file",
+		Err:    err,
+	}
+}
@@ -0,0 +1,88 @@
+package linguo
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTestFile writes content to a file named name in a temporary directory.
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+// checkConfigError checks that err is a *ConfigError for line of file.
+func checkConfigError(t *testing.T, err error, file string, line int, msg string) {
+	t.Helper()
+	var cerr *ConfigError
+	if !errors.As(err, &cerr) {
+		t.Fatalf("got %v, want a *ConfigError", err)
+	}
+	if cerr.File != file || cerr.Line != line || !strings.Contains(cerr.Msg, msg) {
+		t.Errorf("got %s:%d %q, want %s:%d and a message with %q", cerr.File, cerr.Line, cerr.Msg, file, line, msg)
+	}
+}
+
+func TestAffixesBadCondition(t *testing.T) {
+	file := writeTestFile(t, "afixos.dat", "<Suffixes>\ns\ts\t[a-z\tNNS\t0\t0\t0\tS\t0\t-\n</Suffixes>\n")
+	_, err := NewAffixes(file)
+	checkConfigError(t, err, file, 2, "invalid condition '[a-z'")
+}
+
+func TestLocutionsBadEntries(t *testing.T) {
+	for _, tt := range []struct {
+		line, msg string
+	}{
+		{"in_front_of", "expected a multiword"},
+		{"in_front_of in_front_of IN X", "expected A or I"},
+		{"in_front_of $X1_front IN", "invalid lemma '$X1_front'"},
+		{"in_front_of $L4 IN", "the multiword has 3 components"},
+		{"in_front_of in_front_of $2", "invalid tag '$2'"},
+		{"in_front_of in_front_of $4:IN", "the multiword has 3 components"},
+	} {
+		file := writeTestFile(t, "locucions.dat", "at_least at_least RB\n"+tt.line+"\n")
+		_, err := NewLocutions(file)
+		checkConfigError(t, err, file, 2, tt.msg)
+	}
+}
+
+// TestLocutionsReferences builds a multiword whose lemma and tag come from
+// its components.
+func TestLocutionsReferences(t *testing.T) {
+	mo := newTestMacoOptions()
+	mo.LocutionsFile = writeTestFile(t, "locucions.dat", "robot_of_the $L1_$F3 $1:NN A\n")
+	e := newTestEngine(t, mo)
+
+	got := tokensString(e.Workflow("It is from the robot of the episode."))
+	if want := "robot_of_the/robot_the"; !strings.Contains(" "+got+" ", " "+want+" ") {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestDictionaryBadContraction(t *testing.T) {
+	for _, tt := range []struct {
+		entry, msg string
+	}{
+		{"del de+el IN+DT", "no entry for 'de'"},
+		{"del of+the IN+NN", "no entry for 'the' with tag NN"},
+		{"del of+the IN", ""},
+		{"del of+the IN+DT+DT", "2 lemmas for 3 tags"},
+	} {
+		file := writeTestFile(t, "dicc.src", "<IndexType>\nDB_MAP\n</IndexType>\n<Entries>\nof of IN\nthe the DT\n"+tt.entry+"\n</Entries>\n")
+		_, err := NewDictionary("en", file, "", "", false, true)
+		if tt.msg == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tt.entry, err)
+			}
+			continue
+		}
+		checkConfigError(t, err, file, 7, tt.msg)
+	}
+}
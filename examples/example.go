@@ -2,15 +2,21 @@ package main
 
 import (
 	"fmt"
+	"log"
 
 	"github.com/ruggi/linguo"
 )
 
 func main() {
 	engine := linguo.NewEngine()
-	engine.InitNLP("./data", "en")
+	if err := engine.InitNLP("./data", "en"); err != nil {
+		log.Fatal(err)
+	}
 
-	result := engine.NLP.Workflow("Linguo was a grammar-correcting robot created by Lisa Simpson. It is from the eighteenth episode of Season 12.")
+	result, err := engine.Workflow("en", "Linguo was a grammar-correcting robot created by Lisa Simpson. It is from the eighteenth episode of Season 12.")
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	for _, sentence := range result.Sentences {
 		fmt.Printf("Sentence: \"%s\"\n", sentence.Body)
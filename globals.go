@@ -17,6 +17,19 @@ const (
 	MOD_NER
 	MOD_GRAMMAR
 	MOD_CHART
+	MOD_SENSES
+	MOD_SEMDB
+	MOD_UKB
+	MOD_DISAMBIGUATOR
+	MOD_MITIE
+	MOD_LANG_IDENT
+	MOD_PERCEPTRON
+	MOD_NEC
+	MOD_NUMBERS
+	MOD_DATES
+	MOD_QUANTITIES
+	MOD_USERMAP
+	MOD_NLP
 )
 
 type Pair struct {
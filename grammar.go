@@ -93,7 +93,7 @@ type Grammar struct {
 	start       string
 }
 
-func NewGrammar(fname string) *Grammar {
+func NewGrammar(fname string) (*Grammar, error) {
 	this := Grammar{
 		RulesMap:    make(RulesMap),
 		nonterminal: set.New(),
@@ -183,7 +183,7 @@ func NewGrammar(fname string) *Grammar {
 
 	filestr, e := ioutil.ReadFile(fname)
 	if e != nil {
-		CRASH("Error opening file "+fname, MOD_GRAMMAR)
+		return nil, openError(MOD_GRAMMAR, fname, e)
 	}
 	gov := 0
 	havegov := false
@@ -282,6 +282,7 @@ func NewGrammar(fname string) *Grammar {
 
 					fs, e := ioutil.ReadFile(sname)
 					if e != nil {
+						return nil, openError(MOD_GRAMMAR, sname, e)
 					}
 
 					var op, clo string
@@ -393,7 +394,7 @@ func NewGrammar(fname string) *Grammar {
 	}
 
 	TRACE(3, "Grammar loaded", MOD_GRAMMAR)
-	return &this
+	return &this, nil
 }
 
 func (this *Grammar) newRule(h string, ls *list.List, w bool, ngov int) {
@@ -2,10 +2,13 @@ package linguo
 
 import (
 	"container/list"
+	"context"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/petar/GoLLRB/llrb"
 	set "gopkg.in/fatih/set.v0"
@@ -168,7 +171,7 @@ const FORCE_TAGGER = 1
 const FORCE_RETOK = 1
 
 type POSTAGGER interface {
-	annotate(s *Sentence)
+	annotate(ctx context.Context, s *Sentence, kbest int) error
 }
 
 type POSTagger struct {
@@ -184,21 +187,46 @@ func NewPosTagger(r bool, f int) *POSTagger {
 }
 
 func (this *HMMTagger) Analyze(s *Sentence) {
+	this.AnalyzeContext(context.Background(), s)
+}
+
+// AnalyzeContext tags s like Analyze, checking ctx while the trellis is being
+// filled. The analyses selected by the previous modules are kept untouched
+// when tagging is cancelled.
+func (this *HMMTagger) AnalyzeContext(ctx context.Context, s *Sentence) error {
+	return this.analyze(ctx, s, this.kbest)
+}
+
+// analyzeWithOptions tags s keeping opts.KBest sequences instead of the number
+// given when the tagger was loaded.
+func (this *HMMTagger) analyzeWithOptions(ctx context.Context, s *Sentence, opts *WorkflowOptions) error {
+	kbest := this.kbest
+	if opts.KBest > 0 {
+		kbest = opts.KBest
+	}
+	return this.analyze(ctx, s, kbest)
+}
+
+func (this *HMMTagger) analyze(ctx context.Context, s *Sentence, kbest int) error {
 	if s.Len() == 0 {
-		return
+		return nil
 	} else {
-		w := s.Front().Value.(*Word)
-
-		if w.Len() > 0 && w.Front().Value.(*Analysis).getProb() < 0 {
-			CRASH("No lexical probabilities!  Make sure you used the 'probabilities' module before the tagger.", MOD_HMM)
+		for i := s.Front(); i != nil; i = i.Next() {
+			w := i.Value.(*Word)
+			if w.Len() == 0 || w.Front().Value.(*Analysis).getProb() < 0 {
+				return fmt.Errorf("no lexical probabilities for %q: run the morfo stage with probabilities before the tagger", w.getForm())
+			}
 		}
 
-		this.annotate(s)
+		if err := this.annotate(ctx, s, kbest); err != nil {
+			return err
+		}
 
 		if this.force == FORCE_TAGGER {
 			this.forceSelect(s)
 		}
 	}
+	return nil
 }
 
 func (this *POSTagger) forceSelect(se *Sentence) {
@@ -233,11 +261,12 @@ type HMMTagger struct {
 	probInitial    float64
 	probUnobserved float64
 	pA_cache       map[string]float64
+	pA_cacheLock   sync.RWMutex
 	kbest          int
 	c              [3]float64
 }
 
-func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
+func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) (*HMMTagger, error) {
 
 	var prob, coef float64
 	var nom1, aux, ftags string
@@ -265,9 +294,10 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 	cfg.AddSection("Smoothing", SMOOTHING)
 	cfg.AddSection("Forbidden", FORBIDDEN)
 	cfg.AddSection("TagsetFile", TAGSET)
+	cfg.module = MOD_HMM
 
-	if !cfg.Open(hmmFile) {
-		panic("Error opening file " + hmmFile)
+	if err := cfg.Open(hmmFile); err != nil {
+		return nil, err
 	}
 
 	line := ""
@@ -276,6 +306,9 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 		switch cfg.GetSection() {
 		case UNIGRAM:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				nom1 = items[0]
 				prob, _ = strconv.ParseFloat(items[1], 64)
 				this.PTag[nom1] = prob
@@ -283,14 +316,23 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 			}
 		case BIGRAM:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				nom1 = items[0]
 				prob, _ = strconv.ParseFloat(items[1], 64)
 				bg := strings.Split(nom1, ".")
+				if len(bg) < 2 {
+					return nil, cfg.Errorf("invalid bigram '%s'", nom1)
+				}
 				this.PBg.Insert(&Bigram{bg[0], bg[1]}, prob)
 				break
 			}
 		case TRIGRAM:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				nom1 = items[0]
 				prob, _ = strconv.ParseFloat(items[1], 64)
 				this.PTrg[nom1] = prob
@@ -298,18 +340,27 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 			}
 		case INITIAL:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				nom1 = items[0]
 				prob, _ = strconv.ParseFloat(items[1], 64)
 				if nom1 == UNOBS_INITIAL_STATE {
 					this.probInitial = prob
 				} else {
 					bg := strings.Split(nom1, ".")
+					if len(bg) < 2 {
+						return nil, cfg.Errorf("invalid initial bigram '%s'", nom1)
+					}
 					this.PInitial.Insert(&Bigram{bg[0], bg[1]}, prob)
 				}
 				break
 			}
 		case WORD:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				nom1 = items[0]
 				prob, _ = strconv.ParseFloat(items[1], 64)
 				if nom1 == UNOBS_WORD {
@@ -321,6 +372,9 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 			}
 		case SMOOTHING:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				nom1 = items[0]
 				coef, _ = strconv.ParseFloat(items[1], 64)
 				if nom1 == "c1" {
@@ -335,13 +389,19 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 		case FORBIDDEN:
 			{
 				if this.Tags == nil {
-					CRASH(fmt.Sprintf("<TagsetFile> section should appear before <Forbidden> in file %s\n", hmmFile), MOD_HMM)
+					return nil, cfg.Errorf("<TagsetFile> section should appear before <Forbidden>")
+				}
+				if err := cfg.CheckFields(items, 3); err != nil {
+					return nil, err
 				}
 				aux = items[2]
 				err := false
 				l := make([]string, 3)
 				TRACE(4, fmt.Sprintf("reading forbidden (%s)\n", aux), MOD_HMM)
 				ltg := strings.Split(aux, ".")
+				if len(ltg) < 3 {
+					return nil, cfg.Errorf("invalid forbidden trigram '%s'", aux)
+				}
 				for i := 0; i < 3; i++ {
 					TRACE(4, fmt.Sprintf("    ...processing (%s)\n", ltg[i]), MOD_HMM)
 					p := strings.Index(ltg[i], "<")
@@ -390,7 +450,11 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 			{
 				ftags = items[0]
 				TRACE(3, "Loading tagset file "+path+"/"+ftags, MOD_HMM)
-				this.Tags = NewTagset(path + "/" + strings.Replace(ftags, "./", "", -1))
+				tags, err := NewTagset(path + "/" + strings.Replace(ftags, "./", "", -1))
+				if err != nil {
+					return nil, err
+				}
+				this.Tags = tags
 				break
 			}
 		default:
@@ -398,10 +462,10 @@ func NewHMMTagger(hmmFile string, rtk bool, force int, kb int) *HMMTagger {
 		}
 	}
 	if this.probInitial == -1.0 || this.probUnobserved == -1.0 {
-		CRASH("HMM model missing '"+UNOBS_INITIAL_STATE+" and/or "+UNOBS_WORD+"' entries", MOD_HMM)
+		return nil, newConfigError(MOD_HMM, hmmFile, 0, "HMM model missing '%s' and/or '%s' entries", UNOBS_INITIAL_STATE, UNOBS_WORD)
 	}
 	TRACE(3, "Analyzer succesfully created", MOD_HMM)
-	return &this
+	return &this, nil
 }
 
 func (this *HMMTagger) isForbidden(trig string, w *list.Element) bool {
@@ -425,7 +489,9 @@ func (this *HMMTagger) ProbA_log(state_i *Bigram, state_j *Bigram, w *list.Eleme
 		forb = true
 	} else {
 		var d float64 = 0.0
+		this.pA_cacheLock.RLock()
 		d = this.pA_cache[t1t2t3]
+		this.pA_cacheLock.RUnlock()
 		if d != 0 {
 			TRACE(5, fmt.Sprintf("      cached pa(%s)= %f\n", t1t2t3, d), MOD_HMM)
 			return d
@@ -452,7 +518,9 @@ func (this *HMMTagger) ProbA_log(state_i *Bigram, state_j *Bigram, w *list.Eleme
 
 	prob = math.Log(prob)
 	if !forb {
+		this.pA_cacheLock.Lock()
 		this.pA_cache[t1t2t3] = prob
+		this.pA_cacheLock.Unlock()
 	}
 
 	return prob
@@ -539,7 +607,23 @@ func (this *HMMTagger) SequenceProb_log(se Sentence, k int) float64 {
 	return p
 }
 
-func (this *HMMTagger) annotate(se *Sentence) {
+// sortedStates returns the states of a set in order, so that states with the
+// same probability are always ranked the same way in the trellis.
+func sortedStates(s *set.Set) []*Bigram {
+	states := make([]*Bigram, 0, s.Size())
+	for _, k := range s.List() {
+		states = append(states, k.(*Bigram))
+	}
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].First != states[j].First {
+			return states[i].First < states[j].First
+		}
+		return states[i].Second < states[j].Second
+	})
+	return states
+}
+
+func (this *HMMTagger) annotate(ctx context.Context, se *Sentence, kbest int) error {
 	var lemm *list.List
 	var emms *list.Element
 	var emmsant *list.Element
@@ -555,15 +639,13 @@ func (this *HMMTagger) annotate(se *Sentence) {
 	var tag string
 	var t int
 
-	tr := NewTrellis(se.Len()+1, this.kbest)
+	tr := NewTrellis(se.Len()+1, kbest)
 
 	lemm = this.FindStates(se)
 	w = se.Front()
 
 	emms = lemm.Front()
-	for i, _ := range emms.Value.(*set.Set).List() {
-		tk := emms.Value.(*set.Set).List()[i]
-		k := tk.(*Bigram)
+	for _, k := range sortedStates(emms.Value.(*set.Set)) {
 		pi = this.ProbPi_log(k)
 		emm = this.ProbB_log(k, w.Value.(*Word))
 		aux = pi + emm
@@ -575,15 +657,18 @@ func (this *HMMTagger) annotate(se *Sentence) {
 	emms = lemm.Front().Next()
 
 	for w = se.Front().Next(); w != nil; w = w.Next() {
-		for _, k := range emms.Value.(*set.Set).List() {
-			emm = this.ProbB_log(k.(*Bigram), w.Value.(*Word))
-			for _, kant := range emmsant.Value.(*set.Set).List() {
-				if kant.(*Bigram).Second == k.(*Bigram).First {
-					for kb := 0; kb < tr.nbest(t-1, kant.(*Bigram)); kb++ {
-						pant := tr.delta(t-1, kant.(*Bigram), kb)
-						ptrans := this.ProbA_log(kant.(*Bigram), k.(*Bigram), w)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for _, k := range sortedStates(emms.Value.(*set.Set)) {
+			emm = this.ProbB_log(k, w.Value.(*Word))
+			for _, kant := range sortedStates(emmsant.Value.(*set.Set)) {
+				if kant.Second == k.First {
+					for kb := 0; kb < tr.nbest(t-1, kant); kb++ {
+						pant := tr.delta(t-1, kant, kb)
+						ptrans := this.ProbA_log(kant, k, w)
 						aux = pant + ptrans + emm
-						tr.insert(t, k.(*Bigram), kant.(*Bigram), kb, aux)
+						tr.insert(t, k, kant, kb, aux)
 					}
 				}
 			}
@@ -598,10 +683,10 @@ func (this *HMMTagger) annotate(se *Sentence) {
 	w = se.Back()
 	emms = lemm.Back()
 
-	for _, k := range emms.Value.(*set.Set).List() {
-		for kb := 0; kb < tr.nbest(se.Len()-1, k.(*Bigram)); kb++ {
-			aux = tr.delta(se.Len()-1, k.(*Bigram), kb)
-			tr.insert(se.Len(), tr.EndState, k.(*Bigram), kb, aux)
+	for _, k := range sortedStates(emms.Value.(*set.Set)) {
+		for kb := 0; kb < tr.nbest(se.Len()-1, k); kb++ {
+			aux = tr.delta(se.Len()-1, k, kb)
+			tr.insert(se.Len(), tr.EndState, k, kb, aux)
 		}
 	}
 
@@ -649,6 +734,7 @@ func (this *HMMTagger) annotate(se *Sentence) {
 	}
 
 	TRACE(3, "sentence analyzed", MOD_HMM)
+	return nil
 }
 
 type emission_states struct {
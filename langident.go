@@ -0,0 +1,320 @@
+package linguo
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	LANGIDENT_LANGUAGES = 1 + iota
+)
+
+const (
+	PROFILE_ORDER = 1 + iota
+	PROFILE_NGRAMS
+)
+
+// LanguageGuess is a candidate language for a text. Scores of the guesses
+// returned by one call add up to 1.
+type LanguageGuess struct {
+	Lang  string
+	Score float64
+}
+
+// LanguageProfile is a character n-gram model of a language. Profiles are
+// stored one per file:
+//
+//	<Order>
+//	3
+//	</Order>
+//	<NGrams>
+//	_th 1520
+//	the 1404
+//	...
+//	</NGrams>
+//
+// where spaces inside n-grams are written as '_'.
+type LanguageProfile struct {
+	Lang     string
+	order    int
+	ngrams   map[string]float64
+	prefixes map[string]float64
+	alphabet float64
+}
+
+func NewLanguageProfile(lang string, profileFile string) (*LanguageProfile, error) {
+	this := LanguageProfile{
+		Lang:   lang,
+		ngrams: make(map[string]float64),
+	}
+
+	cfg := NewConfigFile(false, "##")
+	cfg.AddSection("Order", PROFILE_ORDER)
+	cfg.AddSection("NGrams", PROFILE_NGRAMS)
+	cfg.module = MOD_LANG_IDENT
+
+	if err := cfg.Open(profileFile); err != nil {
+		return nil, err
+	}
+
+	line := ""
+	for cfg.GetContentLine(&line) {
+		items := strings.Fields(line)
+		switch cfg.GetSection() {
+		case PROFILE_ORDER:
+			{
+				if err := cfg.CheckFields(items, 1); err != nil {
+					return nil, err
+				}
+				n, err := strconv.Atoi(items[0])
+				if err != nil || n < 1 {
+					return nil, cfg.Errorf("invalid order %q", items[0])
+				}
+				this.order = n
+				break
+			}
+		case PROFILE_NGRAMS:
+			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
+				count, err := strconv.ParseFloat(items[1], 64)
+				if err != nil {
+					return nil, cfg.Errorf("invalid count %q", items[1])
+				}
+				this.ngrams[strings.Replace(items[0], "_", " ", -1)] += count
+				break
+			}
+		default:
+			break
+		}
+	}
+
+	if this.order == 0 {
+		return nil, newConfigError(MOD_LANG_IDENT, profileFile, 0, "missing <Order> section")
+	}
+	this.index()
+	return &this, nil
+}
+
+// TrainLanguageProfile builds a profile of the given order from sample text.
+func TrainLanguageProfile(lang string, order int, text string) *LanguageProfile {
+	this := LanguageProfile{
+		Lang:   lang,
+		order:  order,
+		ngrams: make(map[string]float64),
+	}
+	for _, ng := range ngrams(text, order) {
+		this.ngrams[ng]++
+	}
+	this.index()
+	return &this
+}
+
+// Save writes the profile in the format read by NewLanguageProfile.
+func (this *LanguageProfile) Save(profileFile string) error {
+	f, err := os.Create(profileFile)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+
+	keys := make([]string, 0, len(this.ngrams))
+	for ng := range this.ngrams {
+		keys = append(keys, ng)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if this.ngrams[keys[i]] != this.ngrams[keys[j]] {
+			return this.ngrams[keys[i]] > this.ngrams[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	fmt.Fprintf(w, "<Order>\n%d\n</Order>\n<NGrams>\n", this.order)
+	for _, ng := range keys {
+		fmt.Fprintf(w, "%s %g\n", strings.Replace(ng, " ", "_", -1), this.ngrams[ng])
+	}
+	fmt.Fprintln(w, "</NGrams>")
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (this *LanguageProfile) index() {
+	this.prefixes = make(map[string]float64)
+	chars := make(map[rune]bool)
+	for ng, count := range this.ngrams {
+		r := []rune(ng)
+		this.prefixes[string(r[:len(r)-1])] += count
+		for _, c := range r {
+			chars[c] = true
+		}
+	}
+	this.alphabet = float64(len(chars) + 1)
+}
+
+// logProb returns the log-likelihood of the n-grams under the profile, with
+// add-one smoothing.
+func (this *LanguageProfile) logProb(ngrams []string) float64 {
+	p := 0.0
+	for _, ng := range ngrams {
+		r := []rune(ng)
+		prefix := string(r[:len(r)-1])
+		p += math.Log((this.ngrams[ng] + 1) / (this.prefixes[prefix] + this.alphabet))
+	}
+	return p
+}
+
+// ngrams normalizes text (lowercase, runs of non-letters turned into a single
+// space, padded with spaces) and returns its character n-grams, none when the
+// text has no letters.
+func ngrams(text string, order int) []string {
+	norm := []rune{' '}
+	for _, c := range text {
+		if unicode.IsLetter(c) || c == '\'' {
+			norm = append(norm, unicode.ToLower(c))
+		} else if norm[len(norm)-1] != ' ' {
+			norm = append(norm, ' ')
+		}
+	}
+	if len(norm) == 1 {
+		// only padding, there is nothing to compare
+		return nil
+	}
+	if norm[len(norm)-1] != ' ' {
+		norm = append(norm, ' ')
+	}
+
+	out := make([]string, 0, len(norm))
+	for i := 0; i+order <= len(norm); i++ {
+		out = append(out, string(norm[i:i+order]))
+	}
+	return out
+}
+
+// LanguageIdentifier guesses the language of a text by comparing it with the
+// character n-gram profiles listed in its configuration file:
+//
+//	<Languages>
+//	en ./en.dat
+//	es ./es.dat
+//	</Languages>
+//
+// Relative profile paths are taken from the directory of the configuration.
+type LanguageIdentifier struct {
+	profiles []*LanguageProfile
+}
+
+func NewLanguageIdentifier(configFile string) (*LanguageIdentifier, error) {
+	this := LanguageIdentifier{}
+	dir := filepath.Dir(configFile)
+
+	cfg := NewConfigFile(false, "##")
+	cfg.AddSection("Languages", LANGIDENT_LANGUAGES)
+	cfg.module = MOD_LANG_IDENT
+
+	if err := cfg.Open(configFile); err != nil {
+		return nil, err
+	}
+
+	line := ""
+	for cfg.GetContentLine(&line) {
+		items := strings.Fields(line)
+		switch cfg.GetSection() {
+		case LANGIDENT_LANGUAGES:
+			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
+				file := items[1]
+				if !filepath.IsAbs(file) {
+					file = filepath.Join(dir, file)
+				}
+				profile, err := NewLanguageProfile(items[0], file)
+				if err != nil {
+					return nil, err
+				}
+				this.profiles = append(this.profiles, profile)
+				break
+			}
+		default:
+			break
+		}
+	}
+
+	return &this, nil
+}
+
+// Languages returns the languages the identifier knows about.
+func (this *LanguageIdentifier) Languages() []string {
+	langs := make([]string, len(this.profiles))
+	for i, p := range this.profiles {
+		langs[i] = p.Lang
+	}
+	return langs
+}
+
+// Rank returns the candidate languages for text, best first. When langs is
+// given only those languages are considered. Text without letters yields no
+// guesses.
+func (this *LanguageIdentifier) Rank(text string, langs ...string) []LanguageGuess {
+	wanted := make(map[string]bool)
+	for _, l := range langs {
+		wanted[l] = true
+	}
+
+	guesses := make([]LanguageGuess, 0, len(this.profiles))
+	cache := make(map[int][]string)
+	for _, p := range this.profiles {
+		if len(langs) > 0 && !wanted[p.Lang] {
+			continue
+		}
+		ng, ok := cache[p.order]
+		if !ok {
+			ng = ngrams(text, p.order)
+			cache[p.order] = ng
+		}
+		if len(ng) == 0 {
+			return nil
+		}
+		// n-gram models of different order are compared per n-gram
+		guesses = append(guesses, LanguageGuess{Lang: p.Lang, Score: p.logProb(ng) / float64(len(ng))})
+	}
+	if len(guesses) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(guesses, func(i, j int) bool { return guesses[i].Score > guesses[j].Score })
+
+	// turn the per n-gram log-likelihoods into a distribution
+	best := guesses[0].Score
+	total := 0.0
+	for i := range guesses {
+		guesses[i].Score = math.Exp(guesses[i].Score - best)
+		total += guesses[i].Score
+	}
+	for i := range guesses {
+		guesses[i].Score /= total
+	}
+	return guesses
+}
+
+// Identify returns the most likely language of text, or "" if none can be
+// guessed.
+func (this *LanguageIdentifier) Identify(text string, langs ...string) string {
+	guesses := this.Rank(text, langs...)
+	if len(guesses) == 0 {
+		return ""
+	}
+	return guesses[0].Lang
+}
@@ -0,0 +1,114 @@
+package linguo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLanguageProfileRoundTrip(t *testing.T) {
+	p := TrainLanguageProfile("en", 3, "The robot was created by Lisa Simpson. It's from the eighteenth episode.")
+	file := filepath.Join(t.TempDir(), "en.dat")
+	if err := p.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewLanguageProfile("en", file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.order != p.order || !reflect.DeepEqual(loaded.ngrams, p.ngrams) {
+		t.Errorf("got order %d and %v, want order %d and %v", loaded.order, loaded.ngrams, p.order, p.ngrams)
+	}
+	ng := ngrams("the robots", 3)
+	if got, want := loaded.logProb(ng), p.logProb(ng); math.Abs(got-want) > 1e-9 {
+		t.Errorf("got log-probability %v, want %v", got, want)
+	}
+}
+
+func TestLanguageProfileNoOrder(t *testing.T) {
+	file := writeTestFile(t, "en.dat", "<NGrams>\n_th 3\n</NGrams>\n")
+	_, err := NewLanguageProfile("en", file)
+	var cerr *ConfigError
+	if !errors.As(err, &cerr) {
+		t.Errorf("got %v, want a *ConfigError", err)
+	}
+}
+
+func TestRank(t *testing.T) {
+	li, err := NewLanguageIdentifier("testdata/common/lang_ident/ident.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := li.Languages(); !reflect.DeepEqual(got, []string{"en", "es"}) {
+		t.Errorf("got languages %v, want [en es]", got)
+	}
+
+	for _, tt := range []struct{ text, lang string }{
+		{"The children were playing in the garden while their mother was reading.", "en"},
+		{"Los niños jugaban en el jardín mientras su madre leía el periódico.", "es"},
+		{"When will the meeting start?", "en"},
+		{"¿Cuándo empieza la reunión?", "es"},
+	} {
+		guesses := li.Rank(tt.text)
+		if len(guesses) != 2 || guesses[0].Lang != tt.lang {
+			t.Errorf("%q: got %v, want %s first", tt.text, guesses, tt.lang)
+			continue
+		}
+		if sum := guesses[0].Score + guesses[1].Score; math.Abs(sum-1) > 1e-9 || guesses[0].Score < guesses[1].Score {
+			t.Errorf("%q: got scores %v", tt.text, guesses)
+		}
+		if got := li.Identify(tt.text); got != tt.lang {
+			t.Errorf("%q: identified %q, want %s", tt.text, got, tt.lang)
+		}
+	}
+
+	if guesses := li.Rank("Los niños jugaban en el jardín.", "en"); len(guesses) != 1 || guesses[0].Lang != "en" || guesses[0].Score != 1 {
+		t.Errorf("only en: got %v", guesses)
+	}
+	if guesses := li.Rank("The children", "fr"); guesses != nil {
+		t.Errorf("unknown language: got %v, want none", guesses)
+	}
+}
+
+// TestRankNoLetters checks that text without letters gets no guesses,
+// whatever the order of the profiles.
+func TestRankNoLetters(t *testing.T) {
+	for order := 1; order <= 3; order++ {
+		li := &LanguageIdentifier{profiles: []*LanguageProfile{
+			TrainLanguageProfile("en", order, "the robot was created"),
+			TrainLanguageProfile("es", order, "el robot fue creado"),
+		}}
+		for _, text := range []string{"", "   ", "1234 -- 56!", "?"} {
+			if guesses := li.Rank(text); guesses != nil {
+				t.Errorf("order %d, %q: got %v, want none", order, text, guesses)
+			}
+		}
+	}
+}
+
+func TestIdentifyWorkflow(t *testing.T) {
+	e := newTestLanguages(t)
+	defer e.Close()
+
+	if _, _, err := e.IdentifyWorkflow(context.Background(), "The robot was created."); !errors.Is(err, ErrUnknownLanguage) {
+		t.Errorf("no identifier: got %v, want ErrUnknownLanguage", err)
+	}
+	if err := e.InitLanguageIdentifier("testdata"); err != nil {
+		t.Fatal(err)
+	}
+
+	lang, r, err := e.IdentifyWorkflow(context.Background(), "The robot was created by Lisa Simpson.")
+	if err != nil || lang != "en" || len(r.Sentences) != 1 {
+		t.Errorf("got %q, %d sentences and %v, want en and one sentence", lang, len(r.Sentences), err)
+	}
+	// only the loaded languages are candidates
+	if lang, _, err := e.IdentifyWorkflow(context.Background(), "Los niños jugaban en el jardín."); err != nil || lang != "en" {
+		t.Errorf("Spanish text: got %q and %v, want en", lang, err)
+	}
+	if _, _, err := e.IdentifyWorkflow(context.Background(), "1234 !!"); !errors.Is(err, ErrUnknownLanguage) {
+		t.Errorf("no letters: got %v, want ErrUnknownLanguage", err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	set "gopkg.in/fatih/set.v0"
+
+	"github.com/ruggi/linguo/models"
 )
 
 type ProcessorStatus struct {
@@ -71,6 +73,10 @@ func (this *Analysis) Tag() string {
 	}
 }
 
+func (this *Analysis) Lemma() string     { return this.lemma }
+func (this *Analysis) Prob() float64     { return this.prob }
+func (this *Analysis) SetProb(p float64) { this.prob = p }
+
 func (this *Analysis) init(lemma string, tag string) {
 	this.lemma = lemma
 	this.tag = tag
@@ -131,6 +137,8 @@ type Word struct {
 	ambiguousMw   bool
 	alternatives  *list.List
 	start, finish int
+	runeStart     int
+	runeFinish    int
 	inDict        bool
 	locked        bool
 	position      int
@@ -139,6 +147,9 @@ type Word struct {
 	ALL           int
 	user          []string
 	expired       bool
+	neClass       string
+	date          *dateValue
+	timex         *models.Timex
 }
 
 func NewWord() *Word {
@@ -179,6 +190,8 @@ func NewMultiword(f string, a *list.List) *Word {
 		multiword:   a,
 		start:       a.Front().Value.(*Word).getSpanStart(),
 		finish:      a.Back().Value.(*Word).getSpanFinish(),
+		runeStart:   a.Front().Value.(*Word).runeStart,
+		runeFinish:  a.Back().Value.(*Word).runeFinish,
 		inDict:      true,
 		locked:      false,
 		ambiguousMw: false,
@@ -198,12 +211,18 @@ func (this *Word) clone(w Word) {
 	this.phForm = w.phForm
 	this.multiword = w.multiword
 	this.start = w.start
+	this.finish = w.finish
+	this.runeStart = w.runeStart
+	this.runeFinish = w.runeFinish
 	this.inDict = w.inDict
 	this.locked = w.locked
 	this.user = w.user
 	this.alternatives = w.alternatives
 	this.ambiguousMw = w.ambiguousMw
 	this.position = w.position
+	this.neClass = w.neClass
+	this.date = w.date
+	this.timex = w.timex
 }
 
 func (this *Word) copyAnalysis(w *Word) {
@@ -227,6 +246,55 @@ func (this *Word) addAnalysis(analysis *Analysis) {
 	this.Back().Value.(*Analysis).markSelected(0)
 }
 
+func (this *Word) Form() string         { return this.form }
+func (this *Word) LCForm() string       { return this.lcForm }
+func (this *Word) Span() (int, int)     { return this.start, this.finish }
+func (this *Word) RuneSpan() (int, int) { return this.runeStart, this.runeFinish }
+func (this *Word) Lock()                { this.locked = true }
+func (this *Word) Locked() bool         { return this.locked }
+func (this *Word) IsMultiword() bool    { return this.isMultiword() }
+
+// NEClass returns the named entity class (PER, LOC, ORG, MISC) given to the
+// word by the NEC stage, or "" if it was not classified.
+func (this *Word) NEClass() string         { return this.neClass }
+func (this *Word) SetNEClass(class string) { this.neClass = class }
+func (this *Word) FoundInDict() bool       { return this.inDict }
+func (this *Word) SetFoundInDict(b bool)   { this.inDict = b }
+
+// Analyses returns every analysis of the word, selected or not.
+func (this *Word) Analyses() []*Analysis {
+	out := make([]*Analysis, 0, this.Len())
+	for a := this.Front(); a != nil; a = a.Next() {
+		out = append(out, a.Value.(*Analysis))
+	}
+	return out
+}
+
+// SetAnalyses replaces the analyses of the word, all of them selected.
+func (this *Word) SetAnalyses(analysis ...*Analysis) {
+	this.List = this.List.Init()
+	for _, a := range analysis {
+		this.addAnalysis(a)
+	}
+}
+
+// Selected returns the best analysis selected by the pipeline, or nil.
+func (this *Word) Selected() *Analysis {
+	if this.getNAnalysis() == 0 {
+		return nil
+	}
+	if it := this.selectedBegin(0); it.Element != nil {
+		return it.Value.(*Analysis)
+	}
+	return nil
+}
+
+// Select makes a the only selected analysis of the word.
+func (this *Word) Select(a *Analysis) {
+	this.unselectAllAnalysis(0)
+	this.selectAnalysis(a, 0)
+}
+
 func (this *Word) getNAnalysis() int               { return this.Len() }
 func (this *Word) lockAnalysis()                   { this.locked = true }
 func (this *Word) isLocked() bool                  { return this.locked }
@@ -268,6 +336,11 @@ func (this *Word) setSpan(start int, finish int) { this.start = start; this.fini
 func (this *Word) getSpanStart() int             { return this.start }
 func (this *Word) getSpanFinish() int            { return this.finish }
 
+func (this *Word) setRuneSpan(start int, finish int) {
+	this.runeStart = start
+	this.runeFinish = finish
+}
+
 func (this *Word) findTagMatch(re *regexp.Regexp) bool {
 	found := false
 	for an := this.Front(); an != nil && !found; an = an.Next() {
@@ -433,6 +506,17 @@ func NewSentence() *Sentence {
 	return &sentence
 }
 
+func (this *Sentence) ID() string { return this.sentID }
+
+// Words returns the words of the sentence in order.
+func (this *Sentence) Words() []*Word {
+	out := make([]*Word, 0, this.Len())
+	for w := this.Front(); w != nil; w = w.Next() {
+		out = append(out, w.Value.(*Word))
+	}
+	return out
+}
+
 func (this *Sentence) setSentenceID(sid string) { this.sentID = sid }
 func (this *Sentence) getSentenceID() string    { return this.sentID }
 
@@ -453,6 +537,22 @@ func (this *Sentence) clearProcessingStatus() {
 	this.status.Remove(status)
 }
 
+// joinWords replaces the words from start to end with a multiword made of
+// them, returning it. The words are only marked as expired, so that they can
+// be removed with rebuildWordIndex once the sentence has been gone through.
+func (this *Sentence) joinWords(start *list.Element, end *list.Element) *Word {
+	mw := list.New()
+	forms := make([]string, 0)
+	for j := start; j != end.Next(); j = j.Next() {
+		mw.PushBack(j.Value.(*Word))
+		forms = append(forms, j.Value.(*Word).getForm())
+		j.Value.(*Word).expired = true
+	}
+	w := NewMultiword(strings.Join(forms, "_"), mw)
+	this.InsertBefore(w, start)
+	return w
+}
+
 func (this *Sentence) rebuildWordIndex() {
 	this.wpos = make([]*Word, this.Len())
 	i := 0
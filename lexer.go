@@ -22,6 +22,9 @@ func NewLexer(rs []*Pair) *Lexer {
 	}
 
 	this.rules = rs
+	for _, r := range rs {
+		r.first.(*regexp.Regexp).Longest()
+	}
 
 	return &this
 }
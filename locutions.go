@@ -2,7 +2,9 @@ package linguo
 
 import (
 	"container/list"
+	"fmt"
 	"io/ioutil"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -31,6 +33,14 @@ const (
 	LOCUTIONS_ONLYSELECTED
 )
 
+// locutionsRef is a reference of a lemma to the form or the lemma of a
+// component of the multiword, and locutionsTagRef a tag taken from the
+// analyses of a component.
+var (
+	locutionsRef    = regexp.MustCompile(`\$[FL][0-9]`)
+	locutionsTagRef = regexp.MustCompile(`^\$([0-9]+):(.+)$`)
+)
+
 type LocutionStatus struct {
 	AutomatStatus
 	accMW, longestMW *set.Set
@@ -57,7 +67,7 @@ type Locutions struct {
 	onlySelected bool
 }
 
-func NewLocutions(locFile string) *Locutions {
+func NewLocutions(locFile string) (*Locutions, error) {
 	locutions := Locutions{
 		locut:    make(map[string]string),
 		prefixes: set.New(),
@@ -71,12 +81,14 @@ func NewLocutions(locFile string) *Locutions {
 	*/
 	filestr, err := ioutil.ReadFile(locFile)
 	if err != nil {
-		panic("Error opening file " + locFile)
+		return nil, openError(MOD_LOCUTIONS, locFile, err)
 	}
 	lines := strings.Split(string(filestr), "\n")
 
-	for _, line := range lines {
-		locutions.addLocution(line)
+	for n, line := range lines {
+		if err := locutions.addLocution(line); err != nil {
+			return nil, newConfigError(MOD_LOCUTIONS, locFile, n+1, "%s", err.Error())
+		}
 	}
 
 	locutions.initialState = LOCUTIONS_ST_P
@@ -106,7 +118,7 @@ func NewLocutions(locFile string) *Locutions {
 	locutions.trans[LOCUTIONS_ST_M][LOCUTIONS_TK_mwL] = LOCUTIONS_ST_M
 	locutions.trans[LOCUTIONS_ST_M][LOCUTIONS_TK_mwP] = LOCUTIONS_ST_M
 
-	return &locutions
+	return &locutions, nil
 }
 
 func (l *Locutions) BuildMultiword(se *Sentence, start *list.Element, end *list.Element, fs int, built *bool, st *LocutionStatus) *list.Element {
@@ -143,13 +155,21 @@ func (l *Locutions) BuildMultiword(se *Sentence, start *list.Element, end *list.
 	return i
 }
 
-func (l *Locutions) addLocution(line string) {
+// addLocution adds a line of the locutions file: the multiword, one or more
+// lemma and tag pairs and optionally A or I, whether the multiword is
+// ambiguous. Lemmas may use the form ($F2) or the lemma ($L2) of a component
+// and tags may be taken from the analyses of a component starting with a
+// prefix ($2:NC). Malformed entries are rejected with an error.
+func (l *Locutions) addLocution(line string) error {
 	if line == "" {
-		return
+		return nil
 	}
 	var prefix, key, lemma, tag string
 	var p int
 	items := Split(line, " ")
+	if err := checkLocution(items); err != nil {
+		return err
+	}
 	key = items[0]
 
 	lemma = items[1]
@@ -184,6 +204,39 @@ func (l *Locutions) addLocution(line string) {
 		key = key[p+1:]
 		p = strings.Index(key, "_")
 	}
+	return nil
+}
+
+// checkLocution validates the fields of a line of the locutions file.
+func checkLocution(items []string) error {
+	if len(items) < 3 {
+		return fmt.Errorf("expected a multiword followed by lemma and tag")
+	}
+	if len(items)%2 == 0 && items[len(items)-1] != "A" && items[len(items)-1] != "I" {
+		return fmt.Errorf("expected A or I after the lemma and tag pairs, found '%s'", items[len(items)-1])
+	}
+	ncomp := len(strings.Split(items[0], "_"))
+	for k := 1; k+1 < len(items); k += 2 {
+		lemma, tag := items[k], items[k+1]
+		for _, ref := range locutionsRef.FindAllString(lemma, -1) {
+			if n := int(ref[2] - '0'); n < 1 || n > ncomp {
+				return fmt.Errorf("invalid lemma '%s': the multiword has %d components", lemma, ncomp)
+			}
+		}
+		if strings.Contains(locutionsRef.ReplaceAllString(lemma, ""), "$") {
+			return fmt.Errorf("invalid lemma '%s': expected $F or $L and a component number", lemma)
+		}
+		if strings.HasPrefix(tag, "$") {
+			m := locutionsTagRef.FindStringSubmatch(tag)
+			if m == nil {
+				return fmt.Errorf("invalid tag '%s': expected $, a component number, ':' and a tag prefix", tag)
+			}
+			if n, _ := strconv.Atoi(m[1]); n < 1 || n > ncomp {
+				return fmt.Errorf("invalid tag '%s': the multiword has %d components", tag, ncomp)
+			}
+		}
+	}
+	return nil
 }
 
 func (l *Locutions) setOnlySelected(b bool) {
@@ -308,48 +361,32 @@ func (l *Locutions) ValidMultiWord(w *Word, st *LocutionStatus) bool {
 				lemma = items[0]
 				tag = items[1]
 
-				p = If(string(lemma[0]) == "$", 0, -1).(int)
-
-				for p > -1 {
-					lf := lemma[p+1 : p+2]
-					var repl string
-					if lf == "F" {
-						repl = st.components[p-1].getLCForm()
-					} else if lf == "L" {
-						repl = st.components[p-1].getLemma(0)
-					} else {
-						panic("Invalid lemma in locution entry " + form + " " + lemma + " " + tag)
+				// references were checked against the number of components
+				// when the entry was loaded
+				lemma = locutionsRef.ReplaceAllStringFunc(lemma, func(ref string) string {
+					c := st.components[int(ref[2]-'0')-1]
+					if ref[1] == 'F' {
+						return c.getLCForm()
 					}
-
-					lemma = strings.Replace(lemma, lemma[p:p+3], repl, p)
-					p = strings.Index(lemma, "$")
-				}
+					return c.getLemma(0)
+				})
 
 				if string(tag[0]) != "$" {
 					la.PushBack(NewAnalysis(lemma, tag))
 					valid = true
 				} else {
-					p = If(string(tag[0]) == ":", 0, -1).(int)
-					if p > -1 {
-						panic("Invalid tag in locution entry: " + form + " " + lemma + " " + tag)
-					}
-
-					check = tag[p+1:]
-					nc, _ = strconv.Atoi(tag[1 : p-1])
+					m := locutionsTagRef.FindStringSubmatch(tag)
+					check = m[2]
+					nc, _ = strconv.Atoi(m[1])
 
 					found := false
 					for a := st.components[nc-1].Front(); a != nil; a = a.Next() {
-
 						par = a.Value.(*Analysis).getTag()
 						if strings.Index(par, check) == 0 {
 							found = true
 							la.PushBack(NewAnalysis(lemma, par))
 						}
 					}
-
-					if !found {
-
-					}
 					valid = found
 				}
 			}
@@ -1,12 +1,15 @@
 package linguo
 
+import "context"
+
 type MacoOptions struct {
 	Path                                                                                                                              string
 	Lang                                                                                                                              string
 	LocutionsFile, QuantitiesFile, AffixFile, CompoundFile, DictionaryFile, ProbabilityFile, NPdataFile, PunctuationFile, UserMapFile string
 	Decimal, Thousand                                                                                                                 string
 	ProbabilityThreshold                                                                                                              float64
-	InverseDict, RetokContractions                                                                                                    bool
+	InverseDict, RetokContractions, NumbersDetection, DatesDetection                                                                  bool
+	Punctuation                                                                                                                       *Punts
 }
 
 func NewMacoOptions(path, lang string) *MacoOptions {
@@ -27,6 +30,8 @@ func NewMacoOptions(path, lang string) *MacoOptions {
 		ProbabilityThreshold: 0.001,
 		InverseDict:          false,
 		RetokContractions:    true,
+		NumbersDetection:     false,
+		DatesDetection:       false,
 	}
 }
 
@@ -70,6 +75,13 @@ func (m *MacoOptions) PunctuationFilePath(path string) *MacoOptions {
 	return m
 }
 
+// WithPunctuation makes Maco use an already loaded punctuation table instead
+// of reading PunctuationFile, so several languages can share it.
+func (m *MacoOptions) WithPunctuation(p *Punts) *MacoOptions {
+	m.Punctuation = p
+	return m
+}
+
 func (m *MacoOptions) CompoundFilePath(path string) *MacoOptions {
 	m.CompoundFile = m.Path + path
 	return m
@@ -92,6 +104,18 @@ func (this *MacoOptions) SetRetokContractions(b bool) {
 	this.RetokContractions = b
 }
 
+// SetNumbersDetection turns on the recognition of numbers, which uses the
+// separators given to SetNumericalPoint.
+func (this *MacoOptions) SetNumbersDetection(b bool) {
+	this.NumbersDetection = b
+}
+
+// SetDatesDetection turns on the recognition of dates and times, available
+// for English and Spanish.
+func (this *MacoOptions) SetDatesDetection(b bool) {
+	this.DatesDetection = b
+}
+
 type Maco struct {
 	MultiwordsDetection, NumbersDetection, PunctuationDetection, DatesDetection, QuantitiesDetection, DictionarySearch, ProbabilityAssignment, UserMap, NERecognition bool
 	loc                                                                                                                                                               *Locutions
@@ -99,16 +123,13 @@ type Maco struct {
 	prob                                                                                                                                                              *Probability
 	punct                                                                                                                                                             *Punts
 	npm                                                                                                                                                               *NER
-	/*
-		numb *Numbers
-		dates *Dates
-		quant *Quantities
-
-		user *regexp.Regexp
-	*/
+	numb                                                                                                                                                              *Numbers
+	dates                                                                                                                                                             *Dates
+	quant                                                                                                                                                             *Quantities
+	user                                                                                                                                                              *UserMap
 }
 
-func NewMaco(opts *MacoOptions) *Maco {
+func NewMaco(opts *MacoOptions) (*Maco, error) {
 	this := Maco{
 		MultiwordsDetection:   false,
 		NumbersDetection:      false,
@@ -121,48 +142,129 @@ func NewMaco(opts *MacoOptions) *Maco {
 		NERecognition:         false,
 	}
 
-	if opts.PunctuationFile != "" {
-		this.punct = NewPunts(opts.PunctuationFile)
+	var err error
+
+	if opts.UserMapFile != "" {
+		if this.user, err = NewUserMap(opts.UserMapFile); err != nil {
+			return nil, err
+		}
+		this.UserMap = true
+	}
+
+	if opts.NumbersDetection {
+		if this.numb, err = NewNumbers(opts.Lang, opts.Decimal, opts.Thousand); err != nil {
+			return nil, err
+		}
+		this.NumbersDetection = true
+	}
+
+	if opts.Punctuation != nil {
+		this.punct = opts.Punctuation
 		this.PunctuationDetection = true
+	} else if opts.PunctuationFile != "" {
+		if this.punct, err = NewPunts(opts.PunctuationFile); err != nil {
+			return nil, err
+		}
+		this.PunctuationDetection = true
+	}
+
+	if opts.DatesDetection {
+		if this.dates, err = NewDates(opts.Lang); err != nil {
+			return nil, err
+		}
+		this.DatesDetection = true
+	}
+
+	if opts.QuantitiesFile != "" {
+		// the amounts need the numbers module, even when it is off
+		numb := this.numb
+		if numb == nil {
+			if numb, err = NewNumbers(opts.Lang, opts.Decimal, opts.Thousand); err != nil {
+				return nil, err
+			}
+		}
+		if this.quant, err = NewQuantities(opts.QuantitiesFile, numb); err != nil {
+			return nil, err
+		}
+		this.QuantitiesDetection = true
 	}
 
 	if opts.DictionaryFile != "" {
-		this.dic = NewDictionary(opts.Lang, opts.DictionaryFile, opts.AffixFile, opts.CompoundFile, opts.InverseDict, opts.RetokContractions)
+		if this.dic, err = NewDictionary(opts.Lang, opts.DictionaryFile, opts.AffixFile, opts.CompoundFile, opts.InverseDict, opts.RetokContractions); err != nil {
+			return nil, err
+		}
 		this.DictionarySearch = true
 	}
 
 	if opts.LocutionsFile != "" {
-		this.loc = NewLocutions(opts.LocutionsFile)
+		if this.loc, err = NewLocutions(opts.LocutionsFile); err != nil {
+			return nil, err
+		}
 		this.MultiwordsDetection = true
 	}
 
 	if opts.NPdataFile != "" {
-		this.npm = NewNER(opts.NPdataFile)
+		if this.npm, err = NewNER(opts.NPdataFile); err != nil {
+			return nil, err
+		}
 		this.NERecognition = true
 	}
 
 	if opts.ProbabilityFile != "" {
-		this.prob = NewProbability(opts.ProbabilityFile, opts.ProbabilityThreshold)
+		if this.prob, err = NewProbability(opts.ProbabilityFile, opts.ProbabilityThreshold); err != nil {
+			return nil, err
+		}
 		this.ProbabilityAssignment = true
 	}
 
-	return &this
+	return &this, nil
 }
 
 func (this *Maco) Analyze(s *Sentence) {
+	this.analyze(s, true, true)
+}
+
+// analyzeWithOptions runs the enabled submodules on s, skipping proper noun
+// detection unless opts.NER is set and keeping contractions as single words
+// unless opts.RetokContractions is.
+func (this *Maco) analyzeWithOptions(ctx context.Context, s *Sentence, opts *WorkflowOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	this.analyze(s, opts.NER, opts.RetokContractions)
+	return nil
+}
+
+func (this *Maco) analyze(s *Sentence, ner bool, retok bool) {
+	if this.UserMap && this.user != nil {
+		this.user.analyze(s)
+	}
+
+	if this.NumbersDetection && this.numb != nil {
+		this.numb.analyze(s)
+	}
+
 	if this.PunctuationDetection && this.punct != nil {
 		this.punct.analyze(s)
 	}
 
+	if this.DatesDetection && this.dates != nil {
+		this.dates.analyze(s)
+	}
+
+	if this.QuantitiesDetection && this.quant != nil {
+		this.quant.analyze(s)
+	}
+
 	if this.DictionarySearch && this.dic != nil {
-		this.dic.Analyze(s)
+		this.dic.analyze(s, !retok)
 	}
 
 	if this.MultiwordsDetection && this.loc != nil {
 		this.loc.analyze(s)
 	}
 
-	if this.NERecognition && this.npm != nil {
+	if this.NERecognition && this.npm != nil && ner {
 		this.npm.who.analyze(s)
 	}
 
@@ -1,3 +1,6 @@
+//go:build mitie
+// +build mitie
+
 package linguo
 
 /*
@@ -11,47 +14,49 @@ typedef struct {
 	const char* model;
 	double score;
 	const char* value;
+	unsigned long pos;
+	unsigned long len;
 } Entity;
 
-char * my_strcat(const char * str1, const char * str2);
-
+// get_entity returns the i-th detection. value is allocated with malloc and
+// must be released by the caller.
 Entity get_entity(char** tokens,
     const mitie_named_entity_detections* dets,
     unsigned long i) {
 	Entity entity;
 
-	unsigned long pos, len;
+	unsigned long pos, len, k, size;
 
 	pos = mitie_ner_get_detection_position(dets, i);
 	len = mitie_ner_get_detection_length(dets, i);
 
-	double score = mitie_ner_get_detection_score(dets,i);
-	const char* model = mitie_ner_get_detection_tagstr(dets,i);
+	entity.pos = pos;
+	entity.len = len;
+	entity.model = mitie_ner_get_detection_tagstr(dets,i);
+	entity.score = mitie_ner_get_detection_score(dets,i);
 
-	entity.model = model;
-	entity.score = score;
+	size = 1;
+	for (k = pos; k < pos+len; k++)
+	{
+		size += strlen(tokens[k]) + 1;
+	}
 
-	const char* value = "";
-	while(len > 0)
+	char* value = malloc(size);
+	if (value != NULL)
 	{
-		value = my_strcat(value, " ");
-		value = my_strcat(value, tokens[pos++]);
-		len--;
+		value[0] = '\0';
+		for (k = pos; k < pos+len; k++)
+		{
+			strcat(value, " ");
+			strcat(value, tokens[k]);
+		}
 	}
 	entity.value = value;
 	return entity;
 }
 
-char * my_strcat(const char * str1, const char * str2)
-{
-	char * ret = malloc(strlen(str1)+strlen(str2)+1);
-
-	if(ret!=NULL)
-	{
-		sprintf(ret, "%s%s", str1, str2);
-		return ret;
-	}
-	return NULL;
+const char* get_token(char** tokens, unsigned long i) {
+	return tokens[i];
 }
 
 void releaseTokens(char** tokens) {
@@ -66,35 +71,66 @@ void releaseDets(mitie_named_entity_detections* dets) {
 import "C"
 
 import (
-	"fmt"
 	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/abiosoft/semaphore"
 	"github.com/ruggi/linguo/models"
-	set "gopkg.in/fatih/set.v0"
 )
 
 type MITIE struct {
-	ner *C.mitie_named_entity_extractor
-	sem *semaphore.Semaphore
+	ner  *C.mitie_named_entity_extractor
+	sem  *semaphore.Semaphore
+	lock sync.RWMutex
 }
 
-func NewMITIE(filepath string) *MITIE {
-	ner := C.mitie_load_named_entity_extractor(C.CString(filepath))
+func NewMITIE(filepath string) (*MITIE, error) {
+	cpath := C.CString(filepath)
+	defer C.free(unsafe.Pointer(cpath))
+	ner := C.mitie_load_named_entity_extractor(cpath)
+	if ner == nil {
+		return nil, newConfigError(MOD_MITIE, filepath, 0, "error loading named entity extractor")
+	}
 	sem := semaphore.New(4)
 	return &MITIE{
 		ner: ner,
 		sem: sem,
+	}, nil
+}
+
+func newDefaultEntityExtractor(options *NLPOptions) (EntityExtractor, error) {
+	m, err := NewMITIE(options.DataPath + "/" + options.Lang + "/mitie/ner_model.dat")
+	if err != nil {
+		return nil, err
 	}
+	return m, nil
 }
 
+// Release frees the extractor once the running calls to Process are done.
+// Process returns no entities afterwards. It is safe to call Release twice.
 func (this *MITIE) Release() {
-	C.mitie_free(unsafe.Pointer(this.ner))
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	if this.ner != nil {
+		C.mitie_free(unsafe.Pointer(this.ner))
+		this.ner = nil
+	}
 }
 
 func (this *MITIE) Process(body string) []*models.Entity {
-	tokens := C.mitie_tokenize(C.CString(body))
+	this.lock.RLock()
+	defer this.lock.RUnlock()
+	if this.ner == nil {
+		return nil
+	}
+
+	this.sem.Acquire()
+	defer this.sem.Release()
+
+	cbody := C.CString(body)
+	tokens := C.mitie_tokenize(cbody)
+	C.free(unsafe.Pointer(cbody))
 	if tokens == nil {
 		return nil
 	}
@@ -105,23 +141,46 @@ func (this *MITIE) Process(body string) []*models.Entity {
 	}
 	defer C.mitie_free(unsafe.Pointer(dets))
 	num_dets := C.mitie_ner_get_num_detections(dets)
-	duplicates := set.New()
+
+	// MITIE tokens are substrings of body, find where each one starts
+	var offsets []int
+	cursor := 0
+	for k := 0; ; k++ {
+		t := C.get_token(tokens, C.ulong(k))
+		if t == nil {
+			break
+		}
+		tok := C.GoString(t)
+		start := -1
+		if i := strings.Index(body[cursor:], tok); i >= 0 {
+			start = cursor + i
+			cursor = start + len(tok)
+		}
+		offsets = append(offsets, start, start+len(tok))
+	}
 
 	var entities []*models.Entity
 	for i := 0; i < int(num_dets); i++ {
 		centity := C.get_entity(tokens, dets, C.ulong(i))
 		model := C.GoString(centity.model)
 		score := float64(centity.score)
+		if centity.value == nil {
+			continue
+		}
 		value := C.GoString(centity.value)
-		key := fmt.Sprintf("%s:%s", value, model)
-		if duplicates.Has(key) {
+		C.free(unsafe.Pointer(centity.value))
+		if score <= 0.5 {
 			continue
 		}
-		duplicates.Add(key)
-		if score > 0.5 {
-			entity := models.NewEntity(model, score, strings.TrimSpace(value))
-			entities = append(entities, entity)
+
+		entity := models.NewEntity(model, score, strings.TrimSpace(value))
+		first, last := int(centity.pos), int(centity.pos+centity.len)-1
+		if 2*last+1 < len(offsets) && offsets[2*first] >= 0 && offsets[2*last] >= 0 {
+			entity.SetSpan(offsets[2*first], offsets[2*last+1])
+			entity.Value = body[entity.Start:entity.End]
 		}
+		entity.AddSource(ENTITY_SOURCE_MITIE)
+		entities = append(entities, entity)
 	}
 	return entities
 }
@@ -0,0 +1,174 @@
+//go:build mitie
+// +build mitie
+
+package linguo
+
+/*
+#include <stdlib.h>
+#include "mitie.h"
+
+char** make_tokens(unsigned long n) {
+	char** tokens = malloc((n+1) * sizeof(char*));
+	if (tokens != NULL) {
+		tokens[n] = NULL;
+	}
+	return tokens;
+}
+
+void set_token(char** tokens, unsigned long i, char* token) {
+	tokens[i] = token;
+}
+
+void free_tokens(char** tokens, unsigned long n) {
+	unsigned long i;
+	for (i = 0; i < n; i++) {
+		free(tokens[i]);
+	}
+	free(tokens);
+}
+*/
+import "C"
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/ruggi/linguo/models"
+)
+
+// MITIERelations runs MITIE binary relation detectors over every ordered pair
+// of entity mentions in a sentence. The detectors need the feature extractor
+// of the MITIE NER model they were trained with.
+type MITIERelations struct {
+	ner       *MITIE
+	ownNER    bool
+	detectors []*C.mitie_binary_relation_detector
+	names     []string
+}
+
+// NewMITIERelations loads every *.svm relation detector found in dir. ner is
+// the MITIE extractor they were trained with; when nil it is loaded from
+// nerModel.
+func NewMITIERelations(ner *MITIE, nerModel string, dir string) (*MITIERelations, error) {
+	this := MITIERelations{ner: ner}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, openError(MOD_MITIE, dir, err)
+	}
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), ".svm") {
+			continue
+		}
+		file := filepath.Join(dir, f.Name())
+		cfile := C.CString(file)
+		detector := C.mitie_load_binary_relation_detector(cfile)
+		C.free(unsafe.Pointer(cfile))
+		if detector == nil {
+			this.Release()
+			return nil, newConfigError(MOD_MITIE, file, 0, "error loading binary relation detector")
+		}
+		this.detectors = append(this.detectors, detector)
+		this.names = append(this.names, C.GoString(C.mitie_binary_relation_detector_name_string(detector)))
+	}
+	if len(this.detectors) == 0 {
+		return nil, newConfigError(MOD_MITIE, dir, 0, "no binary relation detectors found")
+	}
+
+	if this.ner == nil {
+		if this.ner, err = NewMITIE(nerModel); err != nil {
+			this.Release()
+			return nil, err
+		}
+		this.ownNER = true
+	}
+	return &this, nil
+}
+
+func newDefaultRelationExtractor(options *NLPOptions, extractor EntityExtractor) (RelationExtractor, error) {
+	ner, _ := extractor.(*MITIE)
+	r, err := NewMITIERelations(ner, options.DataPath+"/"+options.Lang+"/mitie/ner_model.dat", options.DataPath+"/"+options.Lang+"/"+options.RelationModelsDir)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Release frees the detectors, and the NER model when it was loaded by
+// NewMITIERelations.
+func (this *MITIERelations) Release() {
+	for _, d := range this.detectors {
+		C.mitie_free(unsafe.Pointer(d))
+	}
+	this.detectors = nil
+	if this.ownNER && this.ner != nil {
+		this.ner.Release()
+	}
+}
+
+func (this *MITIERelations) Extract(sentences []*Sentence, entities []*models.Entity) []*models.Relation {
+	this.ner.lock.RLock()
+	defer this.ner.lock.RUnlock()
+	if this.ner.ner == nil || len(this.detectors) == 0 {
+		return nil
+	}
+
+	bySentence := make(map[int][]*models.Entity)
+	for _, m := range entities {
+		if m.Sentence >= 0 && m.TokenStart >= 0 && m.TokenEnd > m.TokenStart {
+			bySentence[m.Sentence] = append(bySentence[m.Sentence], m)
+		}
+	}
+
+	var relations []*models.Relation
+	for n, s := range sentences {
+		mentions := bySentence[n]
+		if len(mentions) < 2 {
+			continue
+		}
+
+		words, first, count := relationTokens(s)
+		tokens := C.make_tokens(C.ulong(len(words)))
+		if tokens == nil {
+			continue
+		}
+		for i, w := range words {
+			C.set_token(tokens, C.ulong(i), C.CString(w))
+		}
+
+		span := func(m *models.Entity) (C.ulong, C.ulong) {
+			start := first[m.TokenStart]
+			end := first[m.TokenEnd-1] + count[m.TokenEnd-1]
+			return C.ulong(start), C.ulong(end - start)
+		}
+
+		for _, a := range mentions {
+			for _, b := range mentions {
+				if a == b {
+					continue
+				}
+				aStart, aLen := span(a)
+				bStart, bLen := span(b)
+				if C.mitie_entities_overlap(aStart, aLen, bStart, bLen) != 0 {
+					continue
+				}
+				rel := C.mitie_extract_binary_relation(this.ner.ner, tokens, aStart, aLen, bStart, bLen)
+				if rel == nil {
+					continue
+				}
+				for d, detector := range this.detectors {
+					var score C.double
+					if C.mitie_classify_binary_relation(detector, rel, &score) == 0 && score > 0 {
+						relations = append(relations, models.NewRelation(this.names[d], float64(score), a, b))
+					}
+				}
+				C.mitie_free(unsafe.Pointer(rel))
+			}
+		}
+
+		C.free_tokens(tokens, C.ulong(len(words)))
+	}
+	return relations
+}
@@ -0,0 +1,15 @@
+//go:build !mitie
+// +build !mitie
+
+package linguo
+
+// Without the mitie build tag linguo does not link against the MITIE C
+// library and extracts no entities unless an EntityExtractor is given through
+// NLPOptions.
+func newDefaultEntityExtractor(options *NLPOptions) (EntityExtractor, error) {
+	return NoEntityExtractor{}, nil
+}
+
+func newDefaultRelationExtractor(options *NLPOptions, extractor EntityExtractor) (RelationExtractor, error) {
+	return nil, newConfigError(MOD_MITIE, options.RelationModelsDir, 0, "linguo was built without MITIE support, rebuild with -tags mitie")
+}
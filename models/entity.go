@@ -2,17 +2,59 @@ package models
 
 import "fmt"
 
+// Entity is a mention of a named entity. Start and End are byte offsets in
+// the analysed text, End excluded, and RuneStart, RuneEnd the same span
+// counted in runes. Sentence is the index of the sentence in
+// Result.Sentences and TokenStart, TokenEnd the range of its tokens covered
+// by the mention; they are -1 when the mention could not be aligned. Sources
+// lists the backends that proposed the mention.
 type Entity struct {
-	Model string
-	Score float64
-	Value string
+	Model      string
+	Score      float64
+	Value      string
+	Start      int
+	End        int
+	RuneStart  int
+	RuneEnd    int
+	Sentence   int
+	TokenStart int
+	TokenEnd   int
+	Sources    []string
 }
 
 func NewEntity(model string, score float64, value string) *Entity {
 	return &Entity{
-		Model: model,
-		Score: score,
-		Value: value,
+		Model:      model,
+		Score:      score,
+		Value:      value,
+		Sentence:   -1,
+		TokenStart: -1,
+		TokenEnd:   -1,
+	}
+}
+
+func (e *Entity) SetSpan(start, end int) {
+	e.Start = start
+	e.End = end
+}
+
+func (e *Entity) SetRuneSpan(start, end int) {
+	e.RuneStart = start
+	e.RuneEnd = end
+}
+
+func (e *Entity) HasSource(source string) bool {
+	for _, s := range e.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *Entity) AddSource(source string) {
+	if !e.HasSource(source) {
+		e.Sources = append(e.Sources, source)
 	}
 }
 
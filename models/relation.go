@@ -0,0 +1,25 @@
+package models
+
+import "fmt"
+
+// Relation is a typed binary relation between two entity mentions, such as
+// "people.person.place_of_birth" between a PERSON and a LOCATION.
+type Relation struct {
+	Type  string
+	Score float64
+	Arg1  *Entity
+	Arg2  *Entity
+}
+
+func NewRelation(relationType string, score float64, arg1 *Entity, arg2 *Entity) *Relation {
+	return &Relation{
+		Type:  relationType,
+		Score: score,
+		Arg1:  arg1,
+		Arg2:  arg2,
+	}
+}
+
+func (r *Relation) String() string {
+	return fmt.Sprintf("%s:%0.3f:%s:%s", r.Type, r.Score, r.Arg1.Value, r.Arg2.Value)
+}
@@ -1,10 +1,18 @@
 package models
 
+// SentenceEntity is an analysed sentence. Body holds its tokens joined with
+// single spaces; Start, End and RuneStart, RuneEnd give its span in the
+// analysed text, in bytes and in runes, so that text[Start:End] is the
+// sentence as written.
 type SentenceEntity struct {
-	Body     string
-	Tokens   []*TokenEntity
-	Weight   float64
-	Sentence interface{}
+	Body      string
+	Tokens    []*TokenEntity
+	Weight    float64
+	Sentence  interface{}
+	Start     int
+	End       int
+	RuneStart int
+	RuneEnd   int
 }
 
 func NewSentenceEntity() *SentenceEntity {
@@ -19,3 +27,10 @@ func (e *SentenceEntity) SetBody(body string)              { e.Body = body }
 func (e *SentenceEntity) SetSentence(sentence interface{}) { e.Sentence = sentence }
 
 func (e *SentenceEntity) GetSentence() interface{} { return e.Sentence }
+
+func (e *SentenceEntity) SetSpan(start, end, runeStart, runeEnd int) {
+	e.Start = start
+	e.End = end
+	e.RuneStart = runeStart
+	e.RuneEnd = runeEnd
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Types of temporal expressions, as in TIMEX3.
+const (
+	TIMEX_DATE     = "DATE"
+	TIMEX_TIME     = "TIME"
+	TIMEX_DURATION = "DURATION"
+)
+
+// Timex is the value of a temporal expression resolved against a reference
+// time, after TIMEX3. Value is in ISO 8601: a day (2017-03-03), week
+// (2017-W09), weekend (2017-W09-WE), month (2017-03) or year (2017) for
+// dates, a minute (2017-03-03T17:30) or a part of a day (2017-03-03TEV) for
+// times, and a period (P2W, PT3H) for durations. Begin and End are the
+// instants a date or time covers, End excluded, in RFC 3339; they are empty
+// for durations.
+type Timex struct {
+	Type  string
+	Value string
+	Begin string
+	End   string
+}
+
+func NewTimex(tpe string, value string) *Timex {
+	return &Timex{
+		Type:  tpe,
+		Value: value,
+	}
+}
+
+func (t *Timex) SetInterval(begin, end time.Time) {
+	t.Begin = begin.Format(time.RFC3339)
+	t.End = end.Format(time.RFC3339)
+}
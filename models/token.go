@@ -1,14 +1,25 @@
 package models
 
+// TokenEntity is an analysed token. Start and End are its byte offsets in the
+// analysed text, End excluded, and RuneStart, RuneEnd the same span counted
+// in runes. A multiword token spans from its first word to its last. Timex is
+// the resolved value of a date or time token, nil for other tokens and for
+// dates that do not exist.
 type TokenEntity struct {
-	Base   string
-	Lemma  string
-	Pos    string
-	Prob   float64
-	Class  int
-	Role   int
-	Weight float64
-	Sense  int
+	Base      string
+	Lemma     string
+	Pos       string
+	Prob      float64
+	Class     int
+	Role      int
+	Weight    float64
+	Sense     int
+	NEClass   string
+	Start     int
+	End       int
+	RuneStart int
+	RuneEnd   int
+	Timex     *Timex
 }
 
 func NewTokenEntity(base string, lemma string, pos string, prob float64) *TokenEntity {
@@ -19,3 +30,10 @@ func NewTokenEntity(base string, lemma string, pos string, prob float64) *TokenE
 		Prob:  prob,
 	}
 }
+
+func (e *TokenEntity) SetSpan(start, end, runeStart, runeEnd int) {
+	e.Start = start
+	e.End = end
+	e.RuneStart = runeStart
+	e.RuneEnd = runeEnd
+}
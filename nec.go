@@ -0,0 +1,282 @@
+package linguo
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	NEC_NP_TAG = 1 + iota
+	NEC_MODEL
+	NEC_GAZETTEERS
+)
+
+const NEC_NP_TAG_DEFAULT = "^NP"
+
+// NEC classifies the proper nouns found by Maco and the tagger into named
+// entity classes (PER, LOC, ORG, MISC), setting the NEClass of each word.
+// A perceptron model decides using the words around the noun and the
+// gazetteers; with no model the gazetteers alone are used. Its data file is:
+//
+//	<NPTag>
+//	^NP
+//	</NPTag>
+//	<Model>
+//	./nec-model.dat
+//	</Model>
+//	<Gazetteers>
+//	./gazetteer.dat
+//	</Gazetteers>
+//
+// Gazetteer files hold one "form class" pair per line, multiwords joined with
+// '_' as in Lisa_Simpson. Relative paths are taken from the directory of the
+// data file.
+type NEC struct {
+	npTag     *regexp.Regexp
+	model     *averagedPerceptron
+	gazetteer map[string]string
+}
+
+func NewNEC(necFile string) (*NEC, error) {
+	this := NEC{
+		gazetteer: make(map[string]string),
+	}
+	dir := filepath.Dir(necFile)
+	path := func(file string) string {
+		if filepath.IsAbs(file) {
+			return file
+		}
+		return filepath.Join(dir, file)
+	}
+
+	cfg := NewConfigFile(false, "##")
+	cfg.AddSection("NPTag", NEC_NP_TAG)
+	cfg.AddSection("Model", NEC_MODEL)
+	cfg.AddSection("Gazetteers", NEC_GAZETTEERS)
+	cfg.module = MOD_NEC
+
+	if err := cfg.Open(necFile); err != nil {
+		return nil, err
+	}
+
+	line := ""
+	for cfg.GetContentLine(&line) {
+		items := strings.Fields(line)
+		switch cfg.GetSection() {
+		case NEC_NP_TAG:
+			{
+				if err := cfg.CheckFields(items, 1); err != nil {
+					return nil, err
+				}
+				re, err := regexp.Compile(items[0])
+				if err != nil {
+					return nil, cfg.Errorf("invalid tag expression %q: %v", items[0], err)
+				}
+				this.npTag = re
+				break
+			}
+		case NEC_MODEL:
+			{
+				if err := cfg.CheckFields(items, 1); err != nil {
+					return nil, err
+				}
+				model, err := loadAveragedPerceptron(path(items[0]), MOD_NEC)
+				if err != nil {
+					return nil, err
+				}
+				this.model = model
+				break
+			}
+		case NEC_GAZETTEERS:
+			{
+				if err := cfg.CheckFields(items, 1); err != nil {
+					return nil, err
+				}
+				if err := loadGazetteer(path(items[0]), this.gazetteer); err != nil {
+					return nil, err
+				}
+				break
+			}
+		default:
+			break
+		}
+	}
+
+	if this.npTag == nil {
+		this.npTag = regexp.MustCompile(NEC_NP_TAG_DEFAULT)
+	}
+	return &this, nil
+}
+
+// LoadGazetteer reads a gazetteer file, as listed in the <Gazetteers> section
+// of the NEC data file.
+func LoadGazetteer(file string) (map[string]string, error) {
+	gazetteer := make(map[string]string)
+	if err := loadGazetteer(file, gazetteer); err != nil {
+		return nil, err
+	}
+	return gazetteer, nil
+}
+
+func loadGazetteer(file string, gazetteer map[string]string) error {
+	cfg := NewConfigFile(false, "##")
+	cfg.module = MOD_NEC
+	if err := cfg.Open(file); err != nil {
+		return err
+	}
+	for n, line := range cfg.lines {
+		items := strings.Fields(line)
+		if len(items) == 0 || strings.HasPrefix(items[0], "##") {
+			continue
+		}
+		if len(items) < 2 {
+			return newConfigError(MOD_NEC, file, n+1, "expected form and class")
+		}
+		gazetteer[strings.ToLower(items[0])] = items[1]
+	}
+	return nil
+}
+
+// TrainNEC trains the classifier on the entities of a BIO corpus, as read by
+// ReadCoNLL. Each entity is turned into a proper noun joined with '_' the way
+// Maco builds multiwords.
+func TrainNEC(sentences []NERSentence, gazetteer map[string]string, iterations int) *NEC {
+	type example struct {
+		tokens []NERToken
+		i      int
+		class  string
+	}
+	var examples []example
+	seen := make(map[string]bool)
+	var classes []string
+
+	for _, s := range sentences {
+		tokens := make([]NERToken, 0, len(s.Tokens))
+		var spans []example
+		for i := 0; i < len(s.Tokens); i++ {
+			if !strings.HasPrefix(s.Labels[i], "B-") {
+				tokens = append(tokens, s.Tokens[i])
+				continue
+			}
+			class := s.Labels[i][2:]
+			j := i + 1
+			for j < len(s.Tokens) && s.Labels[j] == "I-"+class {
+				j++
+			}
+			forms := make([]string, 0, j-i)
+			for k := i; k < j; k++ {
+				forms = append(forms, s.Tokens[k].Form)
+			}
+			spans = append(spans, example{i: len(tokens), class: class})
+			tokens = append(tokens, NERToken{Form: strings.Join(forms, "_"), Lemma: strings.ToLower(strings.Join(forms, "_")), Tag: s.Tokens[i].Tag})
+			if !seen[class] {
+				seen[class] = true
+				classes = append(classes, class)
+			}
+			i = j - 1
+		}
+		for _, e := range spans {
+			e.tokens = tokens
+			examples = append(examples, e)
+		}
+	}
+
+	this := &NEC{
+		npTag:     regexp.MustCompile(NEC_NP_TAG_DEFAULT),
+		model:     newAveragedPerceptron(classes),
+		gazetteer: gazetteer,
+	}
+	if this.gazetteer == nil {
+		this.gazetteer = make(map[string]string)
+	}
+	for it := 0; it < iterations; it++ {
+		for _, e := range examples {
+			features := this.features(e.tokens, e.i)
+			guess, _ := this.model.best(this.model.scores(features), nil)
+			this.model.update(e.class, guess, features)
+		}
+	}
+	this.model.average()
+	return this
+}
+
+// SaveModel writes the classifier so that it can be listed in the <Model>
+// section of the NEC data file.
+func (this *NEC) SaveModel(modelFile string) error {
+	return this.model.save(modelFile, "linguo NEC model")
+}
+
+func (this *NEC) Analyze(s *Sentence) {
+	var tokens []NERToken
+	for i, w := range s.Words() {
+		a := w.Selected()
+		if a == nil || !this.npTag.MatchString(a.getTag()) {
+			continue
+		}
+		if tokens == nil {
+			tokens = sentenceNERTokens(s)
+		}
+		w.neClass = this.Classify(tokens, i)
+	}
+}
+
+// Classify returns the class of the proper noun at position i.
+func (this *NEC) Classify(tokens []NERToken, i int) string {
+	if this.model == nil || len(this.model.labels) == 0 {
+		return this.gazetteer[strings.ToLower(tokens[i].Form)]
+	}
+	class, _ := this.model.best(this.model.scores(this.features(tokens, i)), nil)
+	return class
+}
+
+func (this *NEC) inGazetteer(form string) bool {
+	_, ok := this.gazetteer[strings.ToLower(form)]
+	return ok
+}
+
+func (this *NEC) features(tokens []NERToken, i int) []string {
+	t := tokens[i]
+	lc := strings.ToLower(t.Form)
+	parts := strings.Split(lc, "_")
+	last := []rune(parts[len(parts)-1])
+
+	features := []string{
+		"bias",
+		"w=" + lc,
+		"shape=" + wordShape(t.Form),
+		"first=" + parts[0],
+		"last=" + string(last),
+		"suf3=" + string(last[len(last)-minInt(3, len(last)):]),
+	}
+	if len(parts) > 1 {
+		features = append(features, "multiword")
+	}
+	for _, p := range parts {
+		features = append(features, "part="+p)
+		if class, ok := this.gazetteer[p]; ok {
+			features = append(features, "gazpart="+class)
+		}
+	}
+	if class, ok := this.gazetteer[lc]; ok {
+		features = append(features, "gaz="+class)
+	}
+	if i > 0 {
+		features = append(features,
+			"w-1="+strings.ToLower(tokens[i-1].Form),
+			"tag-1="+tokens[i-1].Tag)
+	} else {
+		features = append(features, "w-1=<s>")
+	}
+	if i > 1 {
+		features = append(features, "w-2="+strings.ToLower(tokens[i-2].Form))
+	}
+	if i+1 < len(tokens) {
+		features = append(features,
+			"w+1="+strings.ToLower(tokens[i+1].Form),
+			"tag+1="+tokens[i+1].Tag)
+	} else {
+		features = append(features, "w+1=</s>")
+	}
+	return features
+}
@@ -0,0 +1,67 @@
+package linguo
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// neClassesString writes the tokens of r as form/class, or as form alone for
+// the tokens that have no class.
+func neClassesString(r Result) string {
+	var out []string
+	for _, s := range r.Sentences {
+		for _, t := range s.Tokens {
+			if t.NEClass == "" {
+				out = append(out, t.Base)
+			} else {
+				out = append(out, t.Base+"/"+t.NEClass)
+			}
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func checkNEClasses(t *testing.T, e *NLPEngine, input, want string) {
+	t.Helper()
+	r, err := e.WorkflowContext(context.Background(), input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := neClassesString(r); got != want {
+		t.Errorf("%q: got %q, want %q", input, got, want)
+	}
+}
+
+// TestNECGazetteer runs the NEC stage with no model, so that the classes come
+// from the gazetteer alone.
+func TestNECGazetteer(t *testing.T) {
+	e := newTestEngineOptions(t, newTestNLPOptions(newTestMacoOptions()).NECFilePath("/nec.dat"))
+	checkNEClasses(t, e, "Lisa Simpson created Linguo in Springfield.", "Lisa_Simpson/PER created Linguo/MISC in Springfield/LOC .")
+	checkNEClasses(t, e, "Bart Simpson is from Springfield.", "Bart_Simpson is from Springfield/LOC .")
+}
+
+// TestNECModel trains a model on the testdata corpus and loads it back from a
+// NEC data file.
+func TestNECModel(t *testing.T) {
+	dir := t.TempDir()
+	model := filepath.Join(dir, "nec-model.dat")
+	if err := TrainNEC(readTestCoNLL(t), nil, 10).SaveModel(model); err != nil {
+		t.Fatal(err)
+	}
+	nec, err := NewNEC(writeTestFile(t, "nec.dat", "<Model>\n"+model+"\n</Model>\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens := []NERToken{{Form: "Bart_Simpson", Tag: "NP"}, {Form: "visited", Tag: "VBD"}, {Form: "Springfield", Tag: "NP"}}
+	for i, want := range map[int]string{0: "PER", 2: "LOC"} {
+		if got := nec.Classify(tokens, i); got != want {
+			t.Errorf("Classify(%q): got %q, want %q", tokens[i].Form, got, want)
+		}
+	}
+
+	e := newTestEngineOptions(t, newTestNLPOptions(newTestMacoOptions()).WithProcessor("nec-model", nec))
+	checkNEClasses(t, e, "Bart Simpson is from Springfield.", "Bart_Simpson/PER is from Springfield/LOC .")
+}
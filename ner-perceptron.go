@@ -0,0 +1,302 @@
+package linguo
+
+import (
+	"bufio"
+	"io"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/ruggi/linguo/models"
+)
+
+const NER_OUTSIDE = "O"
+
+// NERToken holds what the perceptron NER looks at for each token.
+type NERToken struct {
+	Form  string
+	Lemma string
+	Tag   string
+}
+
+// NERSentence is a sentence of a training corpus, with one BIO label per
+// token (B-PER, I-PER, O, ...).
+type NERSentence struct {
+	Tokens []NERToken
+	Labels []string
+}
+
+// PerceptronNER is a pure Go named entity recognizer: an averaged perceptron
+// labels the tokens of each sentence from left to right with BIO tags, using
+// the form, lemma and PoS tag of the surrounding words. It works on analysed
+// sentences, so it is meant to run after Maco and the tagger.
+type PerceptronNER struct {
+	model *averagedPerceptron
+}
+
+// NewPerceptronNER loads a model written by PerceptronNER.Save.
+func NewPerceptronNER(modelFile string) (*PerceptronNER, error) {
+	model, err := loadAveragedPerceptron(modelFile, MOD_PERCEPTRON)
+	if err != nil {
+		return nil, err
+	}
+	return &PerceptronNER{model: model}, nil
+}
+
+// TrainPerceptronNER trains a model on the sentences, going through them the
+// given number of times.
+func TrainPerceptronNER(sentences []NERSentence, iterations int) *PerceptronNER {
+	seen := map[string]bool{NER_OUTSIDE: true}
+	labels := []string{NER_OUTSIDE}
+	for _, s := range sentences {
+		for _, l := range s.Labels {
+			if !seen[l] {
+				seen[l] = true
+				labels = append(labels, l)
+			}
+		}
+	}
+
+	this := &PerceptronNER{model: newAveragedPerceptron(labels)}
+	r := rand.New(rand.NewSource(1))
+	for it := 0; it < iterations; it++ {
+		for _, n := range r.Perm(len(sentences)) {
+			s := sentences[n]
+			prev, prev2 := "<s>", "<s>"
+			for i := range s.Tokens {
+				features := nerFeatures(s.Tokens, i, prev, prev2)
+				guess, _ := this.model.best(this.model.scores(features), nil)
+				this.model.update(s.Labels[i], guess, features)
+				prev2, prev = prev, guess
+			}
+		}
+	}
+	this.model.average()
+	return this
+}
+
+func (this *PerceptronNER) Save(modelFile string) error {
+	return this.model.save(modelFile, "linguo perceptron NER model")
+}
+
+// Label returns the BIO label of every token and the probability the model
+// gives it.
+func (this *PerceptronNER) Label(tokens []NERToken) ([]string, []float64) {
+	labels := make([]string, len(tokens))
+	probs := make([]float64, len(tokens))
+	prev, prev2 := "<s>", "<s>"
+	for i := range tokens {
+		allowed := func(label string) bool {
+			if !strings.HasPrefix(label, "I-") {
+				return true
+			}
+			return prev == "B-"+label[2:] || prev == label
+		}
+		labels[i], probs[i] = this.model.best(this.model.scores(nerFeatures(tokens, i, prev, prev2)), allowed)
+		prev2, prev = prev, labels[i]
+	}
+	return labels, probs
+}
+
+// Process returns no entities: the perceptron needs the lemmas and tags of
+// the analysed sentences, see ProcessSentences.
+func (this *PerceptronNER) Process(body string) []*models.Entity {
+	return nil
+}
+
+// ProcessSentences labels the analysed sentences and returns a mention for
+// every entity found. The score of a mention is the mean probability of the
+// labels of its tokens.
+func (this *PerceptronNER) ProcessSentences(sentences []*Sentence) []*models.Entity {
+	var entities []*models.Entity
+
+	for _, s := range sentences {
+		words := s.Words()
+		tokens := sentenceNERTokens(s)
+		labels, probs := this.Label(tokens)
+
+		for i := 0; i < len(labels); i++ {
+			if !strings.HasPrefix(labels[i], "B-") {
+				continue
+			}
+			class := labels[i][2:]
+			j := i + 1
+			for j < len(labels) && labels[j] == "I-"+class {
+				j++
+			}
+
+			forms := make([]string, 0, j-i)
+			score := 0.0
+			for k := i; k < j; k++ {
+				forms = append(forms, strings.Replace(tokens[k].Form, "_", " ", -1))
+				score += probs[k]
+			}
+			entity := models.NewEntity(class, score/float64(j-i), strings.Join(forms, " "))
+			entity.SetSpan(words[i].getSpanStart(), words[j-1].getSpanFinish())
+			entity.AddSource(ENTITY_SOURCE_PERCEPTRON)
+			entities = append(entities, entity)
+			i = j - 1
+		}
+	}
+	return entities
+}
+
+func (this *PerceptronNER) Release() {}
+
+func sentenceNERTokens(s *Sentence) []NERToken {
+	tokens := make([]NERToken, 0, s.Len())
+	for _, w := range s.Words() {
+		t := NERToken{Form: w.getForm()}
+		a := w.Selected()
+		if a == nil && w.Len() > 0 {
+			a = w.Front().Value.(*Analysis)
+		}
+		if a != nil {
+			t.Lemma = a.getLemma()
+			t.Tag = a.getTag()
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens
+}
+
+func nerFeatures(tokens []NERToken, i int, prev, prev2 string) []string {
+	t := tokens[i]
+	lc := strings.ToLower(t.Form)
+	runes := []rune(lc)
+
+	features := []string{
+		"bias",
+		"w=" + lc,
+		"shape=" + wordShape(t.Form),
+		"pre3=" + string(runes[:minInt(3, len(runes))]),
+		"suf3=" + string(runes[len(runes)-minInt(3, len(runes)):]),
+		"lemma=" + t.Lemma,
+		"tag=" + t.Tag,
+		"p=" + prev,
+		"p2p=" + prev2 + "|" + prev,
+		"p_tag=" + prev + "|" + t.Tag,
+	}
+	if i == 0 {
+		features = append(features, "first")
+	}
+	if i > 0 {
+		features = append(features,
+			"w-1="+strings.ToLower(tokens[i-1].Form),
+			"tag-1="+tokens[i-1].Tag)
+	} else {
+		features = append(features, "w-1=<s>")
+	}
+	if i+1 < len(tokens) {
+		features = append(features,
+			"w+1="+strings.ToLower(tokens[i+1].Form),
+			"tag+1="+tokens[i+1].Tag,
+			"shape+1="+wordShape(tokens[i+1].Form))
+	} else {
+		features = append(features, "w+1=</s>")
+	}
+	if i+2 < len(tokens) {
+		features = append(features, "tag+2="+tokens[i+2].Tag)
+	}
+
+	for n, f := range features {
+		features[n] = strings.Replace(f, " ", "_", -1)
+	}
+	return features
+}
+
+// wordShape maps uppercase letters to X, lowercase ones to x and digits to d,
+// collapsing runs of the same class: "McDonald's" gives "XxXx'x".
+func wordShape(form string) string {
+	var b strings.Builder
+	var last rune
+	for _, c := range form {
+		var s rune
+		switch {
+		case unicode.IsUpper(c):
+			s = 'X'
+		case unicode.IsLower(c):
+			s = 'x'
+		case unicode.IsDigit(c):
+			s = 'd'
+		default:
+			s = c
+		}
+		if s != last {
+			b.WriteRune(s)
+			last = s
+		}
+	}
+	return b.String()
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// ReadCoNLL reads a CoNLL-style corpus: one token per line with
+// whitespace-separated columns, the form first and the BIO label last, and
+// blank lines between sentences. Lemma and PoS tag are read from the given
+// columns, or left empty when the column is negative; neither can be the last
+// column, which holds the label, so a two-column corpus needs -1 for both.
+// Lines starting with
+// -DOCSTART- are skipped, and IOB1 labels (I- starting an entity) are turned
+// into BIO.
+func ReadCoNLL(r io.Reader, lemmaCol, tagCol int) ([]NERSentence, error) {
+	var sentences []NERSentence
+	current := NERSentence{}
+	flush := func() {
+		if len(current.Tokens) > 0 {
+			sentences = append(sentences, current)
+		}
+		current = NERSentence{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		items := strings.Fields(scanner.Text())
+		if len(items) == 0 {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(items[0], "-DOCSTART-") {
+			continue
+		}
+		if len(items) < 2 || lemmaCol >= len(items) || tagCol >= len(items) {
+			return nil, newConfigError(MOD_PERCEPTRON, "", lineNum, "expected form, label and the requested columns, found %d fields", len(items))
+		}
+		if lemmaCol == len(items)-1 || tagCol == len(items)-1 {
+			return nil, newConfigError(MOD_PERCEPTRON, "", lineNum, "the lemma and tag columns cannot be the label column %d", len(items)-1)
+		}
+
+		t := NERToken{Form: items[0]}
+		if lemmaCol >= 0 {
+			t.Lemma = items[lemmaCol]
+		}
+		if tagCol >= 0 {
+			t.Tag = items[tagCol]
+		}
+
+		label := items[len(items)-1]
+		if strings.HasPrefix(label, "I-") {
+			n := len(current.Labels)
+			if n == 0 || current.Labels[n-1][1:] != label[1:] {
+				label = "B-" + label[2:]
+			}
+		}
+
+		current.Tokens = append(current.Tokens, t)
+		current.Labels = append(current.Labels, label)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return sentences, nil
+}
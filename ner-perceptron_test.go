@@ -0,0 +1,126 @@
+package linguo
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// readTestCoNLL reads the CoNLL 2003 style corpus in testdata, with the PoS
+// tag in column 1.
+func readTestCoNLL(t *testing.T) []NERSentence {
+	t.Helper()
+	f, err := os.Open("testdata/ner/train.conll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	sentences, err := ReadCoNLL(f, -1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sentences
+}
+
+func TestReadCoNLL(t *testing.T) {
+	sentences := readTestCoNLL(t)
+	if len(sentences) != 5 {
+		t.Fatalf("got %d sentences, want 5", len(sentences))
+	}
+	if got, want := sentences[0].Tokens[0], (NERToken{Form: "Lisa", Tag: "NNP"}); got != want {
+		t.Errorf("got token %+v, want %+v", got, want)
+	}
+
+	// IOB1 starts entities with I- unless they follow one of the same class
+	for _, tt := range []struct {
+		n    int
+		want string
+	}{
+		{0, "B-PER I-PER O B-MISC O"},
+		{2, "B-PER I-PER O B-LOC O B-PER O"},
+		{4, "B-PER I-PER B-PER I-PER O O B-LOC O"},
+	} {
+		if got := strings.Join(sentences[tt.n].Labels, " "); got != tt.want {
+			t.Errorf("sentence %d: got %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestReadCoNLLColumns(t *testing.T) {
+	corpus := "Lisa B-PER\nSimpson I-PER\n"
+	if _, err := ReadCoNLL(strings.NewReader(corpus), -1, 1); err == nil {
+		t.Error("tag column 1 of a two-column corpus: got no error")
+	}
+	if _, err := ReadCoNLL(strings.NewReader(corpus), 1, -1); err == nil {
+		t.Error("lemma column 1 of a two-column corpus: got no error")
+	}
+	sentences, err := ReadCoNLL(strings.NewReader(corpus), -1, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(sentences[0].Labels, " "); got != "B-PER I-PER" {
+		t.Errorf("got %q, want %q", got, "B-PER I-PER")
+	}
+}
+
+// TestPerceptronNERTrain trains on the testdata corpus and checks that the
+// model learnt it and reads back the same from its file.
+func TestPerceptronNERTrain(t *testing.T) {
+	sentences := readTestCoNLL(t)
+	ner := TrainPerceptronNER(sentences, 10)
+	for n, s := range sentences {
+		if got, _ := ner.Label(s.Tokens); !reflect.DeepEqual(got, s.Labels) {
+			t.Errorf("sentence %d: got %v, want %v", n, got, s.Labels)
+		}
+	}
+
+	file := filepath.Join(t.TempDir(), "ner.dat")
+	if err := ner.Save(file); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := NewPerceptronNER(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n, s := range sentences {
+		labels, probs := ner.Label(s.Tokens)
+		gotLabels, gotProbs := loaded.Label(s.Tokens)
+		if !reflect.DeepEqual(gotLabels, labels) {
+			t.Errorf("sentence %d: loaded model gives %v, want %v", n, gotLabels, labels)
+		}
+		for i := range probs {
+			if d := gotProbs[i] - probs[i]; d > 1e-9 || d < -1e-9 {
+				t.Errorf("sentence %d, token %d: loaded model gives %f, want %f", n, i, gotProbs[i], probs[i])
+			}
+		}
+	}
+}
+
+// TestPerceptronNERInside checks that Label only gives I-X after B-X or I-X,
+// whatever the model prefers.
+func TestPerceptronNERInside(t *testing.T) {
+	model := newAveragedPerceptron([]string{NER_OUTSIDE, "B-PER", "I-PER"})
+	model.weights["bias"] = map[string]float64{"I-PER": 10}
+	model.weights["w=john"] = map[string]float64{"B-PER": 20}
+	ner := &PerceptronNER{model: model}
+
+	for _, tt := range []struct {
+		forms, want string
+	}{
+		{"Smith", "O"},
+		{"Mr Smith", "O O"},
+		{"John Smith", "B-PER I-PER"},
+		{"John Smith Jr", "B-PER I-PER I-PER"},
+	} {
+		var tokens []NERToken
+		for _, f := range strings.Fields(tt.forms) {
+			tokens = append(tokens, NERToken{Form: f})
+		}
+		labels, _ := ner.Label(tokens)
+		if got := strings.Join(labels, " "); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.forms, got, tt.want)
+		}
+	}
+}
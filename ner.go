@@ -72,7 +72,7 @@ type NERModule struct {
 	splitNPs           bool
 }
 
-func NewNERModule(npFile string) *NERModule {
+func NewNERModule(npFile string) (*NERModule, error) {
 	this := NERModule{
 		TitleLength:        0,
 		AllCapsTitleLength: 0,
@@ -85,9 +85,10 @@ func NewNERModule(npFile string) *NERModule {
 	cfg.AddSection("AllCapsTitleLimit", NER_AC_TITLE_LIMIT)
 	cfg.AddSection("SplitMultiwords", NER_SPLIT_MW)
 	cfg.skipUnknownSections = true
+	cfg.module = MOD_NER
 
-	if !cfg.Open(npFile) {
-		CRASH("Error opening file "+npFile, MOD_NER)
+	if err := cfg.Open(npFile); err != nil {
+		return nil, err
 	}
 
 	line := ""
@@ -119,7 +120,7 @@ func NewNERModule(npFile string) *NERModule {
 		}
 	}
 
-	return &this
+	return &this, nil
 }
 
 func (this *NERModule) BuildMultiword(se *Sentence, start *list.Element, end *list.Element, fs int, built *bool, st *NERStatus) *list.Element {
@@ -140,7 +141,7 @@ func (this *NERModule) BuildMultiword(se *Sentence, start *list.Element, end *li
 	if this.ValidMultiWord(w, st) {
 		if this.splitNPs && start != end {
 			for j := start; j != nil && j != end1; j = j.Next() {
-				if strings.Title(j.Value.(*Word).getForm()) == j.Value.(*Word).getForm() {
+				if IsCapitalized(j.Value.(*Word).getForm()) {
 					j.Value.(*Word).setAnalysis(NewAnalysis(j.Value.(*Word).getLCForm(), this.NETag))
 					j.Value.(*Word).setFoundInDict(true)
 				}
@@ -188,15 +189,16 @@ type NER struct {
 	who *NP
 }
 
-func NewNER(npFile string) *NER {
+func NewNER(npFile string) (*NER, error) {
 	this := NER{}
 
 	cfg := NewConfigFile(false, "##")
 	cfg.AddSection("Type", NER_TYPE)
 	cfg.skipUnknownSections = true
+	cfg.module = MOD_NER
 
-	if !cfg.Open(npFile) {
-		panic("Error opening file " + npFile)
+	if err := cfg.Open(npFile); err != nil {
+		return nil, err
 	}
 
 	nerType := ""
@@ -215,9 +217,13 @@ loop:
 		}
 	}
 	if nerType == "basic" {
-		this.who = NewNP(npFile)
+		who, err := NewNP(npFile)
+		if err != nil {
+			return nil, err
+		}
+		this.who = who
 	}
-	return &this
+	return &this, nil
 }
 
 func (this *NERModule) ResetActions(st *NERStatus)                            {}
@@ -237,7 +243,7 @@ type NP struct {
 	REDateNumPunct *regexp.Regexp
 }
 
-func NewNP(npFile string) *NP {
+func NewNP(npFile string) (*NP, error) {
 	this := NP{
 		fun:            set.New(),
 		punct:          set.New(),
@@ -250,7 +256,11 @@ func NewNP(npFile string) *NP {
 		REClosed:       regexp.MustCompile(NP_RE_CLO),
 		REDateNumPunct: regexp.MustCompile(NP_RE_DNP),
 	}
-	this.NERModule = NewNERModule(npFile)
+	nerModule, err := NewNERModule(npFile)
+	if err != nil {
+		return nil, err
+	}
+	this.NERModule = nerModule
 	this.final = set.New()
 
 	cfg := NewConfigFile(false, "##")
@@ -264,9 +274,10 @@ func NewNP(npFile string) *NP {
 	cfg.AddSection("RE_DateNumPunct", NP_REX_DATNUMPUNT)
 	cfg.AddSection("Affixes", NP_AFFIXES)
 	cfg.skipUnknownSections = true
+	cfg.module = MOD_NER
 
-	if !cfg.Open(npFile) {
-		CRASH("Error opening file "+npFile, MOD_NER)
+	if err := cfg.Open(npFile); err != nil {
+		return nil, err
 	}
 
 	line := ""
@@ -277,7 +288,7 @@ func NewNP(npFile string) *NP {
 		case NP_NER_TYPE:
 			{
 				if strings.ToLower(line) != "basic" {
-					CRASH("Invalid configuration file for 'basic' NER, "+npFile, MOD_NER)
+					return nil, cfg.Errorf("invalid configuration file for 'basic' NER")
 				}
 				break
 			}
@@ -302,6 +313,9 @@ func NewNP(npFile string) *NP {
 
 		case NP_NE_IGNORE:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				key := items[0]
 				tpe, _ := strconv.Atoi(items[1])
 				if IsCapitalized(key) {
@@ -314,24 +328,39 @@ func NewNP(npFile string) *NP {
 
 		case NP_REX_NOUNADJ:
 			{
-				this.RENounAdj = regexp.MustCompile(line)
+				re, err := regexp.Compile(line)
+				if err != nil {
+					return nil, cfg.Errorf("invalid regular expression: %v", err)
+				}
+				this.RENounAdj = re
 				break
 			}
 
 		case NP_REX_CLOSED:
 			{
-				this.REClosed = regexp.MustCompile(line)
+				re, err := regexp.Compile(line)
+				if err != nil {
+					return nil, cfg.Errorf("invalid regular expression: %v", err)
+				}
+				this.REClosed = re
 				break
 			}
 
 		case NP_REX_DATNUMPUNT:
 			{
-				this.REDateNumPunct = regexp.MustCompile(line)
+				re, err := regexp.Compile(line)
+				if err != nil {
+					return nil, cfg.Errorf("invalid regular expression: %v", err)
+				}
+				this.REDateNumPunct = re
 				break
 			}
 
 		case NP_AFFIXES:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				word := items[0]
 				tpe := items[1]
 				if tpe == "SUF" {
@@ -374,7 +403,7 @@ func NewNP(npFile string) *NP {
 	this.trans[NP_ST_FUN][NP_TK_mFun] = NP_ST_FUN
 
 	this.trans[NP_ST_SUF][NP_TK_mSuf] = NP_ST_SUF
-	return &this
+	return &this, nil
 }
 
 func (this *NP) ComputeToken(state int, j *list.Element, se *Sentence) int {
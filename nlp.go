@@ -1,149 +1,415 @@
 package linguo
 
 import (
+	"context"
 	"strings"
+	"sync"
+	"time"
 
 	set "gopkg.in/fatih/set.v0"
 
 	"github.com/ruggi/linguo/models"
 )
 
+// NLPEngine runs the analysis pipeline for one language. Once built its models
+// are only read, and every call keeps its scratch state (splitter session,
+// sentences, trellis, chart) to itself, so a single NLPEngine can be shared by
+// any number of goroutines.
 type NLPEngine struct {
 	options       *NLPOptions
 	tokenizer     *Tokenizer
 	splitter      *Splitter
 	morfo         *Maco
+	timex         *TimexResolver
 	tagger        *HMMTagger
+	nec           *NEC
 	grammar       *Grammar
 	shallowParser *ChartParser
 	sense         *Senses
 	dsb           *UKB
 	disambiguator *Disambiguator
 	filter        *set.Set
-	mitie         *MITIE
+	extractor     EntityExtractor
+	ownExtractor  bool
+	relations     RelationExtractor
+	ownRelations  bool
+	stages        []Stage
+
+	// closeLock is held for reading by every running analysis so that Close
+	// can wait for them before releasing the models.
+	closeLock sync.RWMutex
+	closed    bool
 }
 
-func NewNLPEngine(options *NLPOptions) *NLPEngine {
+func NewNLPEngine(options *NLPOptions) (*NLPEngine, error) {
 	e := &NLPEngine{
 		options: options,
 	}
 
+	var err error
+
 	if options.TokenizerFile != "" {
-		e.tokenizer = NewTokenizer(options.DataPath + "/" + options.Lang + "/" + options.TokenizerFile)
+		if e.tokenizer, err = NewTokenizer(options.DataPath + "/" + options.Lang + "/" + options.TokenizerFile); err != nil {
+			return nil, err
+		}
+		for _, f := range options.TokenizerExtras {
+			if err = e.tokenizer.Extend(options.DataPath + "/" + options.Lang + "/" + f); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	if options.SplitterFile != "" {
-		e.splitter = NewSplitter(options.DataPath + "/" + options.Lang + "/" + options.SplitterFile)
+		if e.splitter, err = NewSplitter(options.DataPath + "/" + options.Lang + "/" + options.SplitterFile); err != nil {
+			return nil, err
+		}
 	}
 
 	if options.MorfoOptions != nil {
-		e.morfo = NewMaco(options.MorfoOptions)
+		if e.morfo, err = NewMaco(options.MorfoOptions); err != nil {
+			return nil, err
+		}
+		if e.morfo.DatesDetection {
+			e.timex = NewTimexResolver()
+		}
 	}
 
 	if options.SenseFile != "" {
-		e.sense = NewSenses(options.DataPath + "/" + options.Lang + "/" + options.SenseFile)
+		if e.sense, err = NewSenses(options.DataPath + "/" + options.Lang + "/" + options.SenseFile); err != nil {
+			return nil, err
+		}
 	}
 
 	if options.TaggerFile != "" {
-		e.tagger = NewHMMTagger(options.DataPath+"/"+options.Lang+"/"+options.TaggerFile, true, FORCE_TAGGER, 1)
+		if e.tagger, err = NewHMMTagger(options.DataPath+"/"+options.Lang+"/"+options.TaggerFile, true, FORCE_TAGGER, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.NECFile != "" {
+		if e.nec, err = NewNEC(options.DataPath + "/" + options.Lang + "/" + options.NECFile); err != nil {
+			return nil, err
+		}
 	}
 
 	if options.ShallowParserFile != "" {
-		e.grammar = NewGrammar(options.DataPath + "/" + options.Lang + "/" + options.ShallowParserFile)
+		if e.grammar, err = NewGrammar(options.DataPath + "/" + options.Lang + "/" + options.ShallowParserFile); err != nil {
+			return nil, err
+		}
 		e.shallowParser = NewChartParser(e.grammar)
 	}
 
 	if options.UKBFile != "" {
-		e.dsb = NewUKB(options.DataPath + "/" + options.Lang + "/" + options.UKBFile)
+		if e.dsb, err = NewUKB(options.DataPath + "/" + options.Lang + "/" + options.UKBFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Disambiguator != nil {
+		e.disambiguator = options.Disambiguator
+	} else if options.DisambiguatorFile != "" {
+		if e.disambiguator, err = NewDisambiguator(options.DataPath + "/" + options.DisambiguatorFile); err != nil {
+			return nil, err
+		}
 	}
 
-	if options.DisambiguatorFile != "" {
-		e.disambiguator = NewDisambiguator(options.DataPath + "/" + options.DisambiguatorFile)
+	if e.stages, err = e.buildStages(); err != nil {
+		return nil, err
 	}
 
-	e.mitie = NewMITIE(options.DataPath + "/" + options.Lang + "/mitie/ner_model.dat")
-	return e
+	if options.EntityExtractor != nil {
+		e.extractor = options.EntityExtractor
+	} else if options.NERModelFile != "" {
+		if e.extractor, err = NewPerceptronNER(options.DataPath + "/" + options.Lang + "/" + options.NERModelFile); err != nil {
+			return nil, err
+		}
+		e.ownExtractor = true
+	} else {
+		if e.extractor, err = newDefaultEntityExtractor(options); err != nil {
+			return nil, err
+		}
+		e.ownExtractor = true
+	}
+
+	if options.RelationExtractor != nil {
+		e.relations = options.RelationExtractor
+	} else if options.RelationModelsDir != "" {
+		if e.relations, err = newDefaultRelationExtractor(options, e.extractor); err != nil {
+			if e.ownExtractor {
+				e.extractor.Release()
+			}
+			return nil, err
+		}
+		e.ownRelations = true
+	}
+	return e, nil
 }
 
+// Result holds the analysed sentences and, when NER is run, the entity
+// mentions found by the extractor and the NP module merged in text order.
+// UnknownEntities counts the proper nouns no other backend confirmed and
+// Relations holds the relations found between the mentions. ReferenceTime is
+// the time the dates in the tokens were resolved against.
 type Result struct {
 	Sentences       []*models.SentenceEntity
 	Entities        []*models.Entity
 	UnknownEntities []*models.UnknownEntity
+	Relations       []*models.Relation
+	ReferenceTime   time.Time
 }
 
+// Workflow runs the whole pipeline on input. It has no way to report an error:
+// on a closed engine, for instance after Engine.Reload replaced it, it logs a
+// warning and returns an empty Result. Code that can run concurrently with
+// Reload or Close should use WorkflowContext, or Engine.Workflow.
 func (e *NLPEngine) Workflow(input string) Result {
+	result, err := e.WorkflowContext(context.Background(), input)
+	if err != nil {
+		WARNING("Workflow: "+err.Error(), MOD_NLP)
+	}
+	return result
+}
+
+// WorkflowContext runs the whole pipeline on input like Workflow, checking ctx
+// between stages and inside the chart parser, the HMM tagger and UKB.
+//
+// When ctx is done it returns ctx.Err() together with a partial Result holding
+// the sentences that went through every stage up to the interrupted one;
+// entities are only extracted when the whole pipeline completes.
+func (e *NLPEngine) WorkflowContext(ctx context.Context, input string) (Result, error) {
+	return e.WorkflowWithOptions(ctx, input, nil)
+}
+
+// WorkflowWithOptions runs the part of the pipeline selected by opts. A nil
+// opts behaves as NewWorkflowOptions().
+func (e *NLPEngine) WorkflowWithOptions(ctx context.Context, input string, opts *WorkflowOptions) (Result, error) {
+	if !e.acquire() {
+		return Result{}, ErrClosed
+	}
+	defer e.release()
+	return e.workflow(ctx, input, opts)
+}
+
+// acquire holds the models of the engine until release is called, so that
+// Close waits for the call using them. It returns false, holding nothing,
+// when the engine is closed.
+func (e *NLPEngine) acquire() bool {
+	e.closeLock.RLock()
+	if e.closed {
+		e.closeLock.RUnlock()
+		return false
+	}
+	return true
+}
+
+func (e *NLPEngine) release() {
+	e.closeLock.RUnlock()
+}
+
+// workflow runs WorkflowWithOptions on an acquired engine.
+func (e *NLPEngine) workflow(ctx context.Context, input string, opts *WorkflowOptions) (Result, error) {
+	if opts == nil {
+		opts = NewWorkflowOptions()
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	stages, err := e.selectStages(opts.Stages)
+	if err != nil {
+		return Result{}, err
+	}
+
 	tokens := e.tokenizer.Tokenize(input, 0)
 
 	sid := e.splitter.OpenSession()
 	sentences := e.splitter.Split(sid, tokens, true)
 	e.splitter.CloseSession(sid)
 
-	for _, sentence := range sentences {
-		if e.morfo != nil {
-			e.morfo.Analyze(sentence)
-		}
-		if e.sense != nil {
-			e.sense.Analyze(sentence)
+	return e.analyze(ctx, input, sentences, stages, opts)
+}
+
+// analyze runs the stages on the sentences of input and builds the Result.
+func (e *NLPEngine) analyze(ctx context.Context, input string, sentences []*Sentence, stages []Stage, opts *WorkflowOptions) (Result, error) {
+	if opts.ReferenceTime.IsZero() {
+		// the same reference for every sentence of the call
+		o := *opts
+		o.ReferenceTime = time.Now()
+		opts = &o
+	}
+	sentences, err := e.runStages(ctx, stages, sentences, opts)
+
+	sentenceEntities := e.sentenceEntities(sentences)
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil || !opts.NER {
+		return Result{Sentences: sentenceEntities, ReferenceTime: opts.ReferenceTime}, err
+	}
+
+	entities := e.extractEntities(input, sentences)
+	var relations []*models.Relation
+	if e.relations != nil {
+		relations = e.relations.Extract(sentences, entities)
+	}
+	return Result{
+		Sentences:       sentenceEntities,
+		Entities:        entities,
+		UnknownEntities: unknownEntities(entities),
+		Relations:       relations,
+		ReferenceTime:   opts.ReferenceTime,
+	}, nil
+}
+
+// Tokenizer returns the tokenizer of the engine, so that abbreviations and
+// rules can be added to it at runtime. It is nil when no TokenizerFile was
+// given.
+func (e *NLPEngine) Tokenizer() *Tokenizer {
+	return e.tokenizer
+}
+
+// Close waits for the running analyses to finish, then releases the entity
+// extractor, unless it was given through NLPOptions, and drops the loaded
+// models. Later calls return ErrClosed.
+func (e *NLPEngine) Close() error {
+	e.closeLock.Lock()
+	defer e.closeLock.Unlock()
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	if e.ownRelations {
+		e.relations.Release()
+	}
+	if e.ownExtractor {
+		e.extractor.Release()
+	}
+	e.tokenizer = nil
+	e.splitter = nil
+	e.morfo = nil
+	e.timex = nil
+	e.tagger = nil
+	e.nec = nil
+	e.grammar = nil
+	e.shallowParser = nil
+	e.sense = nil
+	e.dsb = nil
+	e.disambiguator = nil
+	e.extractor = nil
+	e.relations = nil
+	e.stages = nil
+	return nil
+}
+
+// ProcessBatch runs Workflow on every input using up to workers goroutines
+// and returns the results in input order.
+func (e *NLPEngine) ProcessBatch(inputs []string, workers int) []Result {
+	results := make([]Result, len(inputs))
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for n := 0; n < workers; n++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = e.Workflow(inputs[i])
+			}
+		}()
+	}
+
+	for i := range inputs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// runStages runs the stages in order. Consecutive sentence-level stages are
+// run one sentence at a time, so on error the sentences that went through all
+// of them are kept and the rest dropped.
+func (e *NLPEngine) runStages(ctx context.Context, stages []Stage, sentences []*Sentence, opts *WorkflowOptions) ([]*Sentence, error) {
+	for i := 0; i < len(stages); {
+		if stages[i].Processor == nil {
+			if err := stages[i].analyzeDocument(ctx, sentences); err != nil {
+				return sentences, err
+			}
+			i++
+			continue
 		}
-		if e.tagger != nil {
-			e.tagger.Analyze(sentence)
+
+		j := i
+		for j < len(stages) && stages[j].Processor != nil {
+			j++
 		}
-		if e.shallowParser != nil {
-			e.shallowParser.Analyze(sentence)
+		for done, sentence := range sentences {
+			for _, stage := range stages[i:j] {
+				if err := stage.analyzeSentence(ctx, sentence, opts); err != nil {
+					return sentences[:done], err
+				}
+			}
 		}
+		i = j
 	}
+	return sentences, nil
+}
 
-	if e.dsb != nil {
-		e.dsb.Analyze(sentences)
+// analyzeSentence runs every stage on a single sentence, document-level ones
+// included.
+func (e *NLPEngine) analyzeSentence(ctx context.Context, sentence *Sentence) error {
+	for _, stage := range e.stages {
+		if err := stage.analyzeSentence(ctx, sentence, NewWorkflowOptions()); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
+func (e *NLPEngine) sentenceEntities(sentences []*Sentence) []*models.SentenceEntity {
 	var sentenceEntities []*models.SentenceEntity
-	entitiesFrequency := make(map[string]int64)
 
 	for _, s := range sentences {
 		se := models.NewSentenceEntity()
 		body := ""
 		for ww := s.Front(); ww != nil; ww = ww.Next() {
 			w := ww.Value.(*Word)
-			a := w.Front().Value.(*Analysis)
-			te := models.NewTokenEntity(w.getForm(), a.getLemma(), a.getTag(), a.getProb())
-			if a.getTag() == "NP" {
-				entitiesFrequency[w.getForm()]++
+			var te *models.TokenEntity
+			if w.Len() == 0 {
+				// no morphological stage was run
+				te = models.NewTokenEntity(w.getForm(), "", "", 0)
+			} else {
+				// the analysis chosen by the tagger, or the most likely one
+				a := w.Selected()
+				if a == nil {
+					a = w.Front().Value.(*Analysis)
+				}
+				te = models.NewTokenEntity(w.getForm(), a.getLemma(), a.getTag(), a.getProb())
+				te.NEClass = w.neClass
+				te.Timex = w.timex
 			}
+			rst, rfin := w.RuneSpan()
+			te.SetSpan(w.getSpanStart(), w.getSpanFinish(), rst, rfin)
 			body += w.getForm() + " "
 			se.AddTokenEntity(te)
 		}
 		body = strings.Trim(body, " ")
 		se.SetBody(body)
+		if n := len(se.Tokens); n > 0 {
+			first, last := se.Tokens[0], se.Tokens[n-1]
+			se.SetSpan(first.Start, last.End, first.RuneStart, last.RuneEnd)
+		}
 		se.SetSentence(s)
 
 		sentenceEntities = append(sentenceEntities, se)
 	}
 
-	entities := e.mitie.Process(input)
-	var unknownEntities []*models.UnknownEntity
-
-	for name, frequency := range entitiesFrequency {
-		name = strings.Replace(name, "_", " ", -1)
-
-		found := false
-		for _, e := range entities {
-			if e.Value == name {
-				found = true
-				break
-			}
-		}
-		if found {
-			continue
-		}
-
-		ue := models.NewUnknownEntity(name, frequency)
-		unknownEntities = append(unknownEntities, ue)
-	}
-
-	return Result{
-		Sentences:       sentenceEntities,
-		Entities:        entities,
-		UnknownEntities: unknownEntities,
-	}
+	return sentenceEntities
 }
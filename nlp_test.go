@@ -0,0 +1,195 @@
+package linguo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestMacoOptions returns the morphological options for the small English
+// data in testdata.
+func newTestMacoOptions() *MacoOptions {
+	mo := NewMacoOptions("testdata", "en").
+		PunctuationFilePath("/common/punct.dat").
+		DictionaryFilePath("/en/dicc.src").
+		LocutionsFilePath("/en/locucions.dat").
+		NPdataFilePath("/en/np.dat").
+		ProbabilityFilePath("/en/probabilitats.dat")
+	mo.SetNumbersDetection(true)
+	mo.SetDatesDetection(true)
+	return mo
+}
+
+// newTestNLPOptions returns the options of an engine running the tagger on
+// the English data in testdata with the morphological options mo.
+func newTestNLPOptions(mo *MacoOptions) *NLPOptions {
+	return NewNLPOptions("testdata", "en").
+		TokenizerFilePath("/tokenizer.dat").
+		SplitterFilePath("/splitter.dat").
+		TaggerFilePath("/tagger.dat").
+		WithMorfoOptions(mo)
+}
+
+func newTestEngine(t testing.TB, mo *MacoOptions) *NLPEngine {
+	t.Helper()
+	return newTestEngineOptions(t, newTestNLPOptions(mo))
+}
+
+func newTestEngineOptions(t testing.TB, o *NLPOptions) *NLPEngine {
+	t.Helper()
+	e, err := NewNLPEngine(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+// resultString writes the tokens of r as form/lemma/tag.
+func resultString(r Result) string {
+	var out []string
+	for _, s := range r.Sentences {
+		for _, t := range s.Tokens {
+			out = append(out, fmt.Sprintf("%s/%s/%s", t.Base, t.Lemma, t.Pos))
+		}
+		out = append(out, "|")
+	}
+	return strings.Join(out, " ")
+}
+
+var concurrentInputs = []string{
+	"The robot was created by Lisa Simpson. It is from the eighteenth episode.",
+	"We will meet on Monday, March 3rd, 2017 at half past five.",
+	"It costs twenty-three dollars and the dog weighs 25 kg.",
+	"Mr. Smith paid 1,234.5 for a robot in front of me!",
+	"From 5 to 6 and the first of May, twenty to seven.",
+}
+
+// TestWorkflowConcurrent runs the same engine from several goroutines and
+// checks every result against the sequential one. Run it with -race.
+func TestWorkflowConcurrent(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+
+	want := make([]string, len(concurrentInputs))
+	for i, in := range concurrentInputs {
+		want[i] = resultString(e.Workflow(in))
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for k := range concurrentInputs {
+				i := (k + g) % len(concurrentInputs)
+				if got := resultString(e.Workflow(concurrentInputs[i])); got != want[i] {
+					t.Errorf("goroutine %d, input %d:\ngot  %s\nwant %s", g, i, got, want[i])
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestProcessBatch(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+
+	inputs := make([]string, 0, 4*len(concurrentInputs))
+	for n := 0; n < 4; n++ {
+		inputs = append(inputs, concurrentInputs...)
+	}
+	for _, workers := range []int{0, 1, 3, 100} {
+		results := e.ProcessBatch(inputs, workers)
+		if len(results) != len(inputs) {
+			t.Fatalf("%d workers: %d results for %d inputs", workers, len(results), len(inputs))
+		}
+		for i, r := range results {
+			if got, want := resultString(r), resultString(e.Workflow(inputs[i])); got != want {
+				t.Errorf("%d workers, input %d:\ngot  %s\nwant %s", workers, i, got, want)
+			}
+		}
+	}
+}
+
+// countdownContext is a context that is cancelled once Err has been called n
+// times, so that a call is interrupted at every point that checks it.
+type countdownContext struct {
+	context.Context
+	lock sync.Mutex
+	n    int
+}
+
+func (c *countdownContext) Err() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestWorkflowCancelled(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	input := strings.Join(concurrentInputs, " ")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r, err := e.WorkflowContext(ctx, input)
+	if !errors.Is(err, context.Canceled) || len(r.Sentences) != 0 {
+		t.Errorf("cancelled: got %v and %d sentences, want %v and none", err, len(r.Sentences), context.Canceled)
+	}
+
+	ctx, cancel = context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	if _, err := e.WorkflowContext(ctx, input); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("past deadline: got %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	full := e.Workflow(input)
+	for n := 0; ; n++ {
+		r, err := e.WorkflowContext(&countdownContext{Context: context.Background(), n: n}, input)
+		if err == nil {
+			if got, want := resultString(r), resultString(full); got != want {
+				t.Errorf("%d checks: got %s, want %s", n, got, want)
+			}
+			if n == 0 {
+				t.Error("the pipeline never checks its context")
+			}
+			break
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("%d checks: got %v, want %v", n, err, context.Canceled)
+		}
+		// the partial result holds the sentences done by every stage
+		if len(r.Sentences) > len(full.Sentences) {
+			t.Errorf("%d checks: %d sentences, the whole text has %d", n, len(r.Sentences), len(full.Sentences))
+			continue
+		}
+		partial := Result{Sentences: full.Sentences[:len(r.Sentences)]}
+		if got, want := resultString(r), resultString(partial); got != want {
+			t.Errorf("%d checks: got %s, want %s", n, got, want)
+		}
+		if r.Entities != nil {
+			t.Errorf("%d checks: got entities in a partial result", n)
+		}
+	}
+}
+
+// TestWorkflowClosed checks the results of the workflows on a closed engine.
+func TestWorkflowClosed(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.WorkflowContext(context.Background(), "The robot was created."); !errors.Is(err, ErrClosed) {
+		t.Errorf("WorkflowContext: got %v, want ErrClosed", err)
+	}
+	if r := e.Workflow("The robot was created."); len(r.Sentences) != 0 {
+		t.Errorf("Workflow: got %q, want no sentences", resultString(r))
+	}
+}
@@ -0,0 +1,455 @@
+package linguo
+
+import (
+	"container/list"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// Tags given by the numbers module. The lemma holds the value of the number,
+// with '.' as decimal point and no thousand separators.
+const (
+	NUMBERS_CARDINAL_TAG = "Z"
+	NUMBERS_ORDINAL_TAG  = "Zo"
+)
+
+// Kinds of the words numbers are spelled with.
+const (
+	NUMBERS_TK_NONE = iota
+	NUMBERS_TK_CARD
+	NUMBERS_TK_HUNDREDS
+	NUMBERS_TK_MULT
+	NUMBERS_TK_CONN
+	NUMBERS_TK_DET
+	NUMBERS_TK_ORD
+	NUMBERS_TK_ORDMULT
+)
+
+type numberWord struct {
+	kind  int
+	value int64
+}
+
+// numbersVocab holds the words of a language that make up spelled numbers.
+// bare lists the multipliers that are a number on their own, as "mil".
+// tensConnector is set when the connector joins tens and units, as in
+// "treinta y dos", instead of following a multiplier, as in "one hundred and
+// two". compoundOrdinals is set when ordinals are made of ordinal words only,
+// as "vigésimo tercero", instead of ending a cardinal, as "twenty-third".
+type numbersVocab struct {
+	words            map[string]numberWord
+	bare             map[string]bool
+	tensConnector    bool
+	compoundOrdinals bool
+	ordinals         *regexp.Regexp
+}
+
+var numbersPlain = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u")
+
+func (this *numbersVocab) add(kind int, value int64, words ...string) {
+	for _, w := range words {
+		this.words[w] = numberWord{kind, value}
+		this.words[numbersPlain.Replace(w)] = numberWord{kind, value}
+	}
+}
+
+// addGender adds an ordinal with its feminine and plural forms.
+func (this *numbersVocab) addGender(value int64, masc string) {
+	stem := strings.TrimSuffix(masc, "o")
+	this.add(NUMBERS_TK_ORD, value, masc, stem+"a", stem+"os", stem+"as")
+}
+
+func newNumbersVocab(lang string) *numbersVocab {
+	this := numbersVocab{
+		words: make(map[string]numberWord),
+		bare:  make(map[string]bool),
+	}
+
+	switch lang {
+	case "en":
+		units := []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+			"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+		for n, w := range units {
+			this.add(NUMBERS_TK_CARD, int64(n), w)
+		}
+		tens := []string{"twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+		for n, w := range tens {
+			this.add(NUMBERS_TK_CARD, int64(n+2)*10, w)
+		}
+		this.add(NUMBERS_TK_MULT, 100, "hundred")
+		this.add(NUMBERS_TK_MULT, 1000, "thousand")
+		this.add(NUMBERS_TK_MULT, 1000000, "million")
+		this.add(NUMBERS_TK_MULT, 1000000000, "billion")
+		this.add(NUMBERS_TK_MULT, 1000000000000, "trillion")
+		this.add(NUMBERS_TK_CONN, 0, "and")
+		this.add(NUMBERS_TK_DET, 1, "a")
+
+		ordinals := []string{"", "first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth",
+			"tenth", "eleventh", "twelfth", "thirteenth", "fourteenth", "fifteenth", "sixteenth", "seventeenth", "eighteenth", "nineteenth"}
+		for n, w := range ordinals[1:] {
+			this.add(NUMBERS_TK_ORD, int64(n+1), w)
+		}
+		for n, w := range tens {
+			this.add(NUMBERS_TK_ORD, int64(n+2)*10, strings.TrimSuffix(w, "y")+"ieth")
+		}
+		this.add(NUMBERS_TK_ORDMULT, 100, "hundredth")
+		this.add(NUMBERS_TK_ORDMULT, 1000, "thousandth")
+		this.add(NUMBERS_TK_ORDMULT, 1000000, "millionth")
+		this.add(NUMBERS_TK_ORDMULT, 1000000000, "billionth")
+		this.ordinals = regexp.MustCompile(`^(\d+)(st|nd|rd|th)$`)
+
+	case "es":
+		units := []string{"cero", "uno", "dos", "tres", "cuatro", "cinco", "seis", "siete", "ocho", "nueve",
+			"diez", "once", "doce", "trece", "catorce", "quince", "dieciséis", "diecisiete", "dieciocho", "diecinueve",
+			"veinte", "veintiuno", "veintidós", "veintitrés", "veinticuatro", "veinticinco", "veintiséis", "veintisiete", "veintiocho", "veintinueve"}
+		for n, w := range units {
+			this.add(NUMBERS_TK_CARD, int64(n), w)
+		}
+		this.add(NUMBERS_TK_CARD, 21, "veintiún", "veintiuna")
+		tens := []string{"treinta", "cuarenta", "cincuenta", "sesenta", "setenta", "ochenta", "noventa"}
+		for n, w := range tens {
+			this.add(NUMBERS_TK_CARD, int64(n+3)*10, w)
+		}
+		this.add(NUMBERS_TK_HUNDREDS, 100, "cien", "ciento")
+		hundreds := []string{"dosc", "tresc", "cuatroc", "quin", "seisc", "setec", "ochoc", "novec"}
+		for n, w := range hundreds {
+			this.add(NUMBERS_TK_HUNDREDS, int64(n+2)*100, w+"ientos", w+"ientas")
+		}
+		this.add(NUMBERS_TK_MULT, 1000, "mil")
+		this.add(NUMBERS_TK_MULT, 1000000, "millón", "millones")
+		this.add(NUMBERS_TK_MULT, 1000000000000, "billón", "billones")
+		this.bare["mil"] = true
+		this.add(NUMBERS_TK_CONN, 0, "y")
+		this.tensConnector = true
+		this.add(NUMBERS_TK_DET, 1, "un", "una")
+
+		ordinals := []string{"", "primero", "segundo", "tercero", "cuarto", "quinto", "sexto", "séptimo", "octavo", "noveno"}
+		for n, w := range ordinals[1:] {
+			this.addGender(int64(n+1), w)
+			this.addGender(int64(n+11), "decimo"+numbersPlain.Replace(w))
+		}
+		this.add(NUMBERS_TK_ORD, 1, "primer")
+		this.add(NUMBERS_TK_ORD, 3, "tercer")
+		this.addGender(7, "sétimo")
+		this.addGender(11, "undécimo")
+		this.addGender(12, "duodécimo")
+		tenths := []string{"décimo", "vigésimo", "trigésimo", "cuadragésimo", "quincuagésimo", "sexagésimo", "septuagésimo", "octogésimo", "nonagésimo"}
+		for n, w := range tenths {
+			this.addGender(int64(n+1)*10, w)
+		}
+		hundredths := []string{"centésimo", "ducentésimo", "tricentésimo", "cuadringentésimo", "quingentésimo", "sexcentésimo", "septingentésimo", "octingentésimo", "noningentésimo"}
+		for n, w := range hundredths {
+			this.addGender(int64(n+1)*100, w)
+		}
+		this.addGender(1000, "milésimo")
+		this.compoundOrdinals = true
+		this.ordinals = regexp.MustCompile(`^(\d+)\.?([ºª°]|er)$`)
+	}
+
+	return &this
+}
+
+// numberParser adds up the words of a spelled number one at a time. group
+// is the value below the last multiplier and last that multiplier.
+type numberParser struct {
+	vocab   *numbersVocab
+	total   int64
+	group   int64
+	last    int64
+	ordinal int64
+	prev    int
+	done    bool
+}
+
+// fits tells whether a cardinal or ordinal below one hundred can follow.
+func (this *numberParser) fits(v int64) bool {
+	r := this.group % 100
+	if v == 0 {
+		return this.prev == NUMBERS_TK_NONE
+	}
+	if r == 0 {
+		return this.prev != NUMBERS_TK_CARD
+	}
+	return r >= 20 && r%10 == 0 && v < 10 && (this.prev == NUMBERS_TK_CARD || this.prev == NUMBERS_TK_CONN)
+}
+
+// multiply applies a multiplier, returning false when it cannot follow.
+// Multipliers go in decreasing order, unless one applies to all that comes
+// before it, as in "mil millones".
+func (this *numberParser) multiply(word string, m int64) bool {
+	if this.prev == NUMBERS_TK_CONN {
+		return false
+	}
+	n := this.group
+	if n == 0 {
+		if this.prev == NUMBERS_TK_DET || this.prev == NUMBERS_TK_NONE && this.vocab.bare[word] {
+			n = 1
+		} else if this.prev != NUMBERS_TK_MULT {
+			return false
+		}
+	}
+
+	switch {
+	case m < 1000:
+		if n == 0 || n >= 100 {
+			return false
+		}
+		this.group = n * m
+		return true
+	case this.last == 0 || m < this.last:
+		if n == 0 {
+			return false
+		}
+		this.total += n * m
+	case this.total+n < m:
+		this.total = (this.total + n) * m
+	default:
+		return false
+	}
+	this.group = 0
+	this.last = m
+	return true
+}
+
+func (this *numberParser) add(word string) bool {
+	w, ok := this.vocab.words[word]
+	if !ok || this.done {
+		return false
+	}
+	if this.prev == NUMBERS_TK_ORD && w.kind != NUMBERS_TK_ORD {
+		return false
+	}
+	if this.prev == NUMBERS_TK_DET && w.kind != NUMBERS_TK_MULT && w.kind != NUMBERS_TK_ORDMULT {
+		return false
+	}
+
+	switch w.kind {
+	case NUMBERS_TK_CARD:
+		if !this.fits(w.value) {
+			return false
+		}
+		this.group += w.value
+	case NUMBERS_TK_HUNDREDS:
+		if this.group != 0 {
+			return false
+		}
+		this.group = w.value
+	case NUMBERS_TK_MULT:
+		if !this.multiply(word, w.value) {
+			return false
+		}
+	case NUMBERS_TK_CONN:
+		r := this.group % 100
+		if this.vocab.tensConnector && (this.prev != NUMBERS_TK_CARD || r < 20 || r%10 != 0) {
+			return false
+		}
+		if !this.vocab.tensConnector && this.prev != NUMBERS_TK_MULT {
+			return false
+		}
+	case NUMBERS_TK_DET:
+		if this.prev != NUMBERS_TK_NONE {
+			return false
+		}
+	case NUMBERS_TK_ORD:
+		if this.vocab.compoundOrdinals {
+			if this.prev != NUMBERS_TK_NONE && (this.prev != NUMBERS_TK_ORD || w.value >= magnitude(this.ordinal)) {
+				return false
+			}
+		} else {
+			if !this.fits(w.value) {
+				return false
+			}
+			this.done = true
+		}
+		this.group += w.value
+		this.ordinal = w.value
+	case NUMBERS_TK_ORDMULT:
+		if !this.multiply(word, w.value) {
+			return false
+		}
+		this.ordinal = w.value
+		this.done = true
+	}
+	this.prev = w.kind
+	return true
+}
+
+// addToken adds a token, made of several words when it is hyphenated.
+func (this *numberParser) addToken(form string) bool {
+	for _, part := range strings.Split(form, "-") {
+		if !this.add(part) {
+			return false
+		}
+	}
+	return true
+}
+
+// complete tells whether the words added so far make a whole number.
+func (this *numberParser) complete() bool {
+	return this.prev != NUMBERS_TK_NONE && this.prev != NUMBERS_TK_CONN && this.prev != NUMBERS_TK_DET
+}
+
+func (this *numberParser) value() int64 {
+	return this.total + this.group
+}
+
+// magnitude returns the power of ten of the first digit of v.
+func magnitude(v int64) int64 {
+	m := int64(1)
+	for v >= 10 {
+		v /= 10
+		m *= 10
+	}
+	return m
+}
+
+// Numbers recognizes cardinal and ordinal numbers, written with digits
+// ("1,234.5", "18th") or spelled out ("twenty-three", "one hundred and
+// five", "eighteenth"). Numbers of several words are joined in a multiword.
+// Words are known for English and Spanish; other languages only get the
+// numbers written with digits.
+type Numbers struct {
+	vocab    *numbersVocab
+	decimal  string
+	thousand string
+	digits   *regexp.Regexp
+}
+
+// numbersDecimalComma holds the languages writing decimals after a comma and
+// thousands after a period.
+var numbersDecimalComma = map[string]bool{
+	"as": true, "ca": true, "de": true, "es": true, "fr": true, "gl": true, "hr": true,
+	"it": true, "nb": true, "pt": true, "ru": true, "sl": true,
+}
+
+// numbersVocabulary tells whether the numbers module knows the words of lang,
+// and not only the numbers written with digits.
+func numbersVocabulary(lang string) bool {
+	return len(newNumbersVocab(lang).words) > 0
+}
+
+// NewNumbers creates the numbers module for lang. The decimal and thousand
+// separators default to the ones of the language when empty.
+func NewNumbers(lang string, decimal string, thousand string) (*Numbers, error) {
+	if decimal == "" {
+		decimal = If(numbersDecimalComma[lang], ",", ".").(string)
+	}
+	if thousand == "" {
+		thousand = If(decimal == ",", ".", ",").(string)
+	}
+	if decimal == thousand {
+		return nil, newConfigError(MOD_NUMBERS, "", 0, "decimal and thousand separators are both '%s'", decimal)
+	}
+
+	this := Numbers{
+		vocab:    newNumbersVocab(lang),
+		decimal:  decimal,
+		thousand: thousand,
+	}
+	d, t := regexp.QuoteMeta(decimal), regexp.QuoteMeta(thousand)
+	this.digits = regexp.MustCompile(`^(\d{1,3}(?:` + t + `\d{3})+|\d+)(?:` + d + `(\d+))?$`)
+
+	TRACE(1, "Module created successfully", MOD_NUMBERS)
+
+	return &this, nil
+}
+
+// digitValue returns the value of a number written with digits.
+func (this *Numbers) digitValue(form string) (*big.Rat, bool) {
+	m := this.digits.FindStringSubmatch(form)
+	if m == nil {
+		return nil, false
+	}
+	num := strings.Replace(m[1], this.thousand, "", -1)
+	if m[2] != "" {
+		num += "." + m[2]
+	}
+	return new(big.Rat).SetString(num)
+}
+
+// match returns the last word of the number starting at i, with its lemma
+// and tag, or nil when there is none.
+func (this *Numbers) match(i *list.Element) (*list.Element, string, string) {
+	form := i.Value.(*Word).getLCForm()
+
+	if v, ok := this.digitValue(form); ok {
+		end := i
+		if next := i.Next(); next != nil && !next.Value.(*Word).isLocked() {
+			if w, ok := this.vocab.words[next.Value.(*Word).getLCForm()]; ok && w.kind == NUMBERS_TK_MULT {
+				v.Mul(v, new(big.Rat).SetInt64(w.value))
+				end = next
+			}
+		}
+		return end, numberLemma(v), NUMBERS_CARDINAL_TAG
+	}
+
+	if this.vocab.ordinals != nil {
+		if m := this.vocab.ordinals.FindStringSubmatch(form); m != nil {
+			v, _ := new(big.Rat).SetString(m[1])
+			return i, numberLemma(v), NUMBERS_ORDINAL_TAG
+		}
+	}
+
+	var end *list.Element
+	var value, ordinal int64
+	p := numberParser{vocab: this.vocab}
+	for j := i; j != nil && !j.Value.(*Word).isLocked(); j = j.Next() {
+		if !p.addToken(j.Value.(*Word).getLCForm()) {
+			break
+		}
+		if p.complete() {
+			end, value, ordinal = j, p.value(), p.ordinal
+		}
+	}
+	if end == nil {
+		return nil, "", ""
+	}
+	return end, numberLemma(new(big.Rat).SetInt64(value)), If(ordinal > 0, NUMBERS_ORDINAL_TAG, NUMBERS_CARDINAL_TAG).(string)
+}
+
+func (this *Numbers) Analyze(se *Sentence) {
+	this.analyze(se)
+}
+
+func (this *Numbers) analyze(se *Sentence) {
+	found := false
+	for i := se.Front(); i != nil; i = i.Next() {
+		if i.Value.(*Word).isLocked() {
+			continue
+		}
+		end, lemma, tag := this.match(i)
+		if end == nil {
+			continue
+		}
+
+		w := i.Value.(*Word)
+		if end != i {
+			w = se.joinWords(i, end)
+			found = true
+		}
+		w.setAnalysis(NewAnalysis(lemma, tag))
+		TRACE(3, "Found number "+w.getForm()+" ("+lemma+")", MOD_NUMBERS)
+		i = end
+	}
+	if found {
+		se.rebuildWordIndex()
+	}
+}
+
+// numberLemma writes v in decimal notation, without exponent. A number read
+// with n decimals has a denominator 2^a·5^b with a, b <= n, so it is written
+// exactly with at most as many decimals as the denominator has bits.
+func numberLemma(v *big.Rat) string {
+	if v.IsInt() {
+		return v.Num().String()
+	}
+	ten := big.NewRat(10, 1)
+	scaled := new(big.Rat).Set(v)
+	prec := 0
+	for !scaled.IsInt() && prec < v.Denom().BitLen() {
+		scaled.Mul(scaled, ten)
+		prec++
+	}
+	return v.FloatString(prec)
+}
@@ -0,0 +1,100 @@
+package linguo
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// numbersString runs the numbers module of lang on the words of text and
+// writes the result as form/lemma/tag, or form alone for the words that are
+// not numbers.
+func numbersString(t *testing.T, lang, text string) string {
+	t.Helper()
+	numb, err := NewNumbers(lang, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSentence()
+	for _, f := range strings.Fields(text) {
+		s.PushBack(NewWordFromLemma(f))
+	}
+	numb.Analyze(s)
+
+	var out []string
+	for _, w := range s.Words() {
+		if a := w.Selected(); a != nil {
+			out = append(out, w.getForm()+"/"+a.getLemma()+"/"+a.getTag())
+		} else {
+			out = append(out, w.getForm())
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestNumbers(t *testing.T) {
+	for _, tt := range []struct {
+		lang, text, want string
+	}{
+		{"en", "twenty-three robots", "twenty-three/23/Z robots"},
+		{"en", "the eighteenth episode", "the eighteenth/18/Zo episode"},
+		{"en", "the 18th episode", "the 18th/18/Zo episode"},
+		{"en", "one hundred and five robots", "one_hundred_and_five/105/Z robots"},
+		{"en", "two hundred and", "two_hundred/200/Z and"},
+		{"en", "1,234.50 dollars", "1,234.50/1234.5/Z dollars"},
+		{"en", "2.5 million robots", "2.5_million/2500000/Z robots"},
+		{"es", "treinta y dos robots", "treinta_y_dos/32/Z robots"},
+		{"es", "el vigésimo tercero", "el vigésimo_tercero/23/Zo"},
+		{"es", "1.234,5 euros", "1.234,5/1234.5/Z euros"},
+	} {
+		if got := numbersString(t, tt.lang, tt.text); got != tt.want {
+			t.Errorf("%s: %q: got %q, want %q", tt.lang, tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestNumberLemma checks that no decimal is lost, however many there are.
+func TestNumberLemma(t *testing.T) {
+	for _, want := range []string{"0.5", "1234.125", "0." + strings.Repeat("0", 68) + "1", "3." + strings.Repeat("14159", 20)} {
+		v, _ := new(big.Rat).SetString(want)
+		if got := numberLemma(v); got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestNumbersSeparators(t *testing.T) {
+	tests := []struct {
+		lang, form, want string
+	}{
+		{"en", "1,234.5", "2469/2"},
+		{"es", "1.234,5", "2469/2"},
+		{"it", "1.234,5", "2469/2"},
+		{"de", "1.234,5", "2469/2"},
+		{"fr", "3,25", "13/4"},
+		{"pt", "1.000", "1000"},
+		{"xx", "1,000.25", "4001/4"},
+	}
+	for _, tt := range tests {
+		numb, err := NewNumbers(tt.lang, "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, ok := numb.digitValue(tt.form)
+		if !ok {
+			t.Errorf("%s: %q is not a number", tt.lang, tt.form)
+			continue
+		}
+		if v.RatString() != tt.want {
+			t.Errorf("%s: %q is %s, want %s", tt.lang, tt.form, v.RatString(), tt.want)
+		}
+	}
+}
+
+func TestNumbersVocabulary(t *testing.T) {
+	for lang, want := range map[string]bool{"en": true, "es": true, "it": false, "de": false} {
+		if got := numbersVocabulary(lang); got != want {
+			t.Errorf("numbersVocabulary(%q) = %v, want %v", lang, got, want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package linguo
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/ruggi/linguo/models"
+)
+
+// valueExtractor is an EntityExtractor that finds the given values without
+// offsets, leaving them to locateEntities.
+type valueExtractor []string
+
+func (this valueExtractor) Process(body string) []*models.Entity {
+	var found []*models.Entity
+	for _, v := range this {
+		if strings.Contains(body, v) {
+			found = append(found, models.NewEntity("PER", 1, v))
+		}
+	}
+	return found
+}
+
+func (valueExtractor) Release() {}
+
+var offsetInputs = []string{
+	"The robot was created by Lisa Simpson. It is from the eighteenth episode.",
+	"   Mr. Smith  met me in front of   the house on March 3rd, 2017.\n\n",
+	"\t\nWe will meet on Monday at half past five!  Lisa Simpson paid 1,234.5 dollars.   ",
+	"  Zoë bought a café in front of Lisa Simpson's house.  ",
+}
+
+// surfaceOf checks that surface, the text a span covers, is form, the words
+// of a multiword being joined by "_" in the form and by any run of spaces in
+// the text.
+func surfaceOf(form, surface string) bool {
+	if !strings.Contains(form, "_") {
+		return form == surface
+	}
+	return strings.Replace(form, "_", "", -1) == strings.Join(strings.Fields(surface), "")
+}
+
+// checkRunes checks that the rune span covers the same text as the byte one.
+func checkRunes(t *testing.T, input, what string, start, end, runeStart, runeEnd int) {
+	t.Helper()
+	if start < 0 || start > end || end > len(input) {
+		t.Errorf("%s: span [%d:%d] out of the input", what, start, end)
+		return
+	}
+	if got := utf8.RuneCountInString(input[:start]); got != runeStart {
+		t.Errorf("%s: rune start %d, want %d", what, runeStart, got)
+	}
+	if got := runeStart + utf8.RuneCountInString(input[start:end]); got != runeEnd {
+		t.Errorf("%s: rune end %d, want %d", what, runeEnd, got)
+	}
+}
+
+// TestOffsetsRoundTrip checks that the offsets of every token, sentence and
+// entity give back their surface form in the input.
+func TestOffsetsRoundTrip(t *testing.T) {
+	o := newTestNLPOptions(newTestMacoOptions()).
+		WithEntityExtractor(valueExtractor{"Lisa Simpson", "Smith"})
+	e := newTestEngineOptions(t, o)
+
+	for _, input := range offsetInputs {
+		r := e.Workflow(input)
+		if len(r.Sentences) == 0 {
+			t.Fatalf("%q: no sentences", input)
+		}
+		multiwords := 0
+		for n, s := range r.Sentences {
+			if len(s.Tokens) == 0 {
+				t.Fatalf("%q: sentence %d has no tokens", input, n)
+			}
+			checkRunes(t, input, "sentence "+s.Body, s.Start, s.End, s.RuneStart, s.RuneEnd)
+			if first, last := s.Tokens[0], s.Tokens[len(s.Tokens)-1]; s.Start != first.Start || s.End != last.End {
+				t.Errorf("%q: sentence %d spans [%d:%d], its tokens [%d:%d]", input, n, s.Start, s.End, first.Start, last.End)
+			}
+			forms := make([]string, len(s.Tokens))
+			for k, tok := range s.Tokens {
+				forms[k] = tok.Base
+				if strings.Contains(tok.Base, "_") {
+					multiwords++
+				}
+				checkRunes(t, input, "token "+tok.Base, tok.Start, tok.End, tok.RuneStart, tok.RuneEnd)
+				if surface := input[tok.Start:tok.End]; !surfaceOf(tok.Base, surface) {
+					t.Errorf("%q: token %q has surface %q", input, tok.Base, surface)
+				}
+			}
+			if surface := input[s.Start:s.End]; !surfaceOf(strings.Join(forms, "_"), surface) {
+				t.Errorf("%q: sentence %q has surface %q", input, s.Body, surface)
+			}
+		}
+		if multiwords == 0 {
+			t.Errorf("%q: no multiwords", input)
+		}
+
+		fromExtractor := 0
+		for _, m := range r.Entities {
+			checkRunes(t, input, "entity "+m.Value, m.Start, m.End, m.RuneStart, m.RuneEnd)
+			if surface := input[m.Start:m.End]; strings.Join(strings.Fields(surface), " ") != m.Value {
+				t.Errorf("%q: entity %q has surface %q", input, m.Value, surface)
+			}
+			if m.HasSource(ENTITY_SOURCE_EXTRACTOR) {
+				fromExtractor++
+			}
+		}
+		if strings.Contains(input, "Lisa Simpson") && fromExtractor == 0 {
+			t.Errorf("%q: no entities from the extractor", input)
+		}
+	}
+}
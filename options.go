@@ -1,16 +1,28 @@
 package linguo
 
+import "time"
+
 type NLPOptions struct {
 	DataPath          string
 	Lang              string
 	TokenizerFile     string
+	TokenizerExtras   []string
 	SplitterFile      string
 	TaggerFile        string
 	ShallowParserFile string
+	NECFile           string
 	SenseFile         string
 	UKBFile           string
 	DisambiguatorFile string
+	NERModelFile      string
 	MorfoOptions      *MacoOptions
+	Disambiguator     *Disambiguator
+	EntityExtractor   EntityExtractor
+	RelationModelsDir string
+	RelationExtractor RelationExtractor
+	Processors        []Stage
+	Stages            []string
+	DisabledStages    []string
 }
 
 func NewNLPOptions(dataPath string, lang string) *NLPOptions {
@@ -25,6 +37,13 @@ func (o *NLPOptions) TokenizerFilePath(path string) *NLPOptions {
 	return o
 }
 
+// TokenizerExtraFilePath adds a supplementary tokenizer file, loaded after
+// TokenizerFile to extend it with more rules and abbreviations.
+func (o *NLPOptions) TokenizerExtraFilePath(path string) *NLPOptions {
+	o.TokenizerExtras = append(o.TokenizerExtras, path)
+	return o
+}
+
 func (o *NLPOptions) SplitterFilePath(path string) *NLPOptions {
 	o.SplitterFile = path
 	return o
@@ -40,6 +59,11 @@ func (o *NLPOptions) ShallowParserFilePath(path string) *NLPOptions {
 	return o
 }
 
+func (o *NLPOptions) NECFilePath(path string) *NLPOptions {
+	o.NECFile = path
+	return o
+}
+
 func (o *NLPOptions) SenseFilePath(path string) *NLPOptions {
 	o.SenseFile = path
 	return o
@@ -55,7 +79,152 @@ func (o *NLPOptions) DisambiguatorFilePath(path string) *NLPOptions {
 	return o
 }
 
+// NERModelFilePath makes the engine extract entities with a PerceptronNER
+// model instead of the default extractor.
+func (o *NLPOptions) NERModelFilePath(path string) *NLPOptions {
+	o.NERModelFile = path
+	return o
+}
+
 func (o *NLPOptions) WithMorfoOptions(options *MacoOptions) *NLPOptions {
 	o.MorfoOptions = options
 	return o
 }
+
+// WithDisambiguator makes the engine use an already loaded knowledge base
+// instead of reading DisambiguatorFile.
+func (o *NLPOptions) WithDisambiguator(d *Disambiguator) *NLPOptions {
+	o.Disambiguator = d
+	return o
+}
+
+// WithEntityExtractor replaces the default entity extractor (MITIE when built
+// with the mitie tag, none otherwise). The engine does not release it on Close.
+func (o *NLPOptions) WithEntityExtractor(x EntityExtractor) *NLPOptions {
+	o.EntityExtractor = x
+	return o
+}
+
+// RelationModelsPath makes the engine run the MITIE binary relation
+// detectors (*.svm) found in the given directory of the language data. It
+// needs the mitie build tag.
+func (o *NLPOptions) RelationModelsPath(path string) *NLPOptions {
+	o.RelationModelsDir = path
+	return o
+}
+
+// WithRelationExtractor sets the relation extractor. As for the entity
+// extractor, the engine does not release it on Close.
+func (o *NLPOptions) WithRelationExtractor(x RelationExtractor) *NLPOptions {
+	o.RelationExtractor = x
+	return o
+}
+
+// WithProcessor registers a custom sentence-level stage under name. Unless
+// WithStages says otherwise it runs after the built-in stages.
+func (o *NLPOptions) WithProcessor(name string, p Processor) *NLPOptions {
+	o.Processors = append(o.Processors, Stage{Name: name, Processor: p})
+	return o
+}
+
+// WithDocumentProcessor registers a custom stage that receives every sentence
+// of the document at once.
+func (o *NLPOptions) WithDocumentProcessor(name string, p DocumentProcessor) *NLPOptions {
+	o.Processors = append(o.Processors, Stage{Name: name, DocumentProcessor: p})
+	return o
+}
+
+// WithStages sets the exact order of the stages to run, built-in (STAGE_*) or
+// custom. Stages not listed are not run.
+func (o *NLPOptions) WithStages(names ...string) *NLPOptions {
+	o.Stages = names
+	return o
+}
+
+func (o *NLPOptions) DisableStages(names ...string) *NLPOptions {
+	o.DisabledStages = append(o.DisabledStages, names...)
+	return o
+}
+
+// files returns the data files read by NewNLPEngine for o.
+func (o *NLPOptions) files() []string {
+	var files []string
+	for _, f := range []string{o.TokenizerFile, o.SplitterFile, o.TaggerFile, o.ShallowParserFile, o.NECFile, o.SenseFile, o.UKBFile, o.NERModelFile} {
+		if f != "" {
+			files = append(files, o.DataPath+"/"+o.Lang+"/"+f)
+		}
+	}
+	if o.TokenizerFile != "" {
+		for _, f := range o.TokenizerExtras {
+			files = append(files, o.DataPath+"/"+o.Lang+"/"+f)
+		}
+	}
+	if o.Disambiguator == nil && o.DisambiguatorFile != "" {
+		files = append(files, o.DataPath+"/"+o.DisambiguatorFile)
+	}
+	if m := o.MorfoOptions; m != nil {
+		morfo := []string{m.LocutionsFile, m.QuantitiesFile, m.AffixFile, m.CompoundFile, m.DictionaryFile, m.ProbabilityFile, m.NPdataFile, m.UserMapFile}
+		if m.Punctuation == nil {
+			morfo = append(morfo, m.PunctuationFile)
+		}
+		for _, f := range morfo {
+			if f != "" {
+				files = append(files, f)
+			}
+		}
+	}
+	return files
+}
+
+// WorkflowOptions selects how much of the pipeline a single call runs, so
+// that one loaded engine can serve both cheap and full analyses.
+type WorkflowOptions struct {
+	Stages            []string
+	KBest             int
+	NER               bool
+	RetokContractions bool
+	ReferenceTime     time.Time
+}
+
+// NewWorkflowOptions returns the options used by Workflow: every stage, the
+// k-best count the tagger was loaded with, NER on, contractions split as
+// configured in MacoOptions and dates resolved against the time of the call.
+func NewWorkflowOptions() *WorkflowOptions {
+	return &WorkflowOptions{
+		NER:               true,
+		RetokContractions: true,
+	}
+}
+
+// OnlyStages restricts the call to the named stages, which keep the engine
+// order. With no names only tokenization and splitting are done. The tagger,
+// NEC and parser stages need morfo to be named too.
+func (o *WorkflowOptions) OnlyStages(names ...string) *WorkflowOptions {
+	o.Stages = append([]string{}, names...)
+	return o
+}
+
+func (o *WorkflowOptions) KBestSequences(k int) *WorkflowOptions {
+	o.KBest = k
+	return o
+}
+
+// WithNER turns proper noun detection in Maco and entity extraction on or off.
+func (o *WorkflowOptions) WithNER(b bool) *WorkflowOptions {
+	o.NER = b
+	return o
+}
+
+// WithRetokContractions set to false keeps contractions as single words even
+// when the dictionary is configured to split them.
+func (o *WorkflowOptions) WithRetokContractions(b bool) *WorkflowOptions {
+	o.RetokContractions = b
+	return o
+}
+
+// WithReferenceTime sets the time relative dates such as "next Tuesday" are
+// resolved against, and the time zone of the resolved values.
+func (o *WorkflowOptions) WithReferenceTime(t time.Time) *WorkflowOptions {
+	o.ReferenceTime = t
+	return o
+}
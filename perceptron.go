@@ -0,0 +1,214 @@
+package linguo
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PERCEPTRON_LABELS = 1 + iota
+	PERCEPTRON_WEIGHTS
+)
+
+// averagedPerceptron is a multiclass linear model over string features.
+// While training, the sum of every weight over time is kept so that the
+// averaged weights, which generalize much better, can be computed at the end.
+type averagedPerceptron struct {
+	labels  []string
+	weights map[string]map[string]float64
+
+	// training state
+	totals  map[string]map[string]float64
+	stamps  map[string]map[string]int
+	instant int
+}
+
+func newAveragedPerceptron(labels []string) *averagedPerceptron {
+	return &averagedPerceptron{
+		labels:  labels,
+		weights: make(map[string]map[string]float64),
+		totals:  make(map[string]map[string]float64),
+		stamps:  make(map[string]map[string]int),
+	}
+}
+
+// scores returns the score of every label for the features.
+func (this *averagedPerceptron) scores(features []string) map[string]float64 {
+	scores := make(map[string]float64, len(this.labels))
+	for _, l := range this.labels {
+		scores[l] = 0
+	}
+	for _, f := range features {
+		for l, w := range this.weights[f] {
+			scores[l] += w
+		}
+	}
+	return scores
+}
+
+// best returns the highest scoring label among the allowed ones, together
+// with its softmax probability over them.
+func (this *averagedPerceptron) best(scores map[string]float64, allowed func(label string) bool) (string, float64) {
+	bestLabel := ""
+	bestScore := math.Inf(-1)
+	for _, l := range this.labels {
+		if allowed != nil && !allowed(l) {
+			continue
+		}
+		if scores[l] > bestScore {
+			bestLabel, bestScore = l, scores[l]
+		}
+	}
+	total := 0.0
+	for _, l := range this.labels {
+		if allowed != nil && !allowed(l) {
+			continue
+		}
+		total += math.Exp(scores[l] - bestScore)
+	}
+	return bestLabel, 1 / total
+}
+
+// update moves the weights of the features towards truth and away from guess.
+func (this *averagedPerceptron) update(truth, guess string, features []string) {
+	this.instant++
+	if truth == guess {
+		return
+	}
+	for _, f := range features {
+		this.step(f, truth, 1)
+		this.step(f, guess, -1)
+	}
+}
+
+func (this *averagedPerceptron) step(f, label string, v float64) {
+	if this.weights[f] == nil {
+		this.weights[f] = make(map[string]float64)
+		this.totals[f] = make(map[string]float64)
+		this.stamps[f] = make(map[string]int)
+	}
+	w := this.weights[f][label]
+	this.totals[f][label] += float64(this.instant-this.stamps[f][label]) * w
+	this.stamps[f][label] = this.instant
+	this.weights[f][label] = w + v
+}
+
+// average replaces the weights with their average over training and drops
+// the training state.
+func (this *averagedPerceptron) average() {
+	for f, ws := range this.weights {
+		for l, w := range ws {
+			total := this.totals[f][l] + float64(this.instant-this.stamps[f][l])*w
+			avg := total / float64(this.instant)
+			if avg == 0 {
+				delete(ws, l)
+			} else {
+				ws[l] = avg
+			}
+		}
+		if len(ws) == 0 {
+			delete(this.weights, f)
+		}
+	}
+	this.totals = nil
+	this.stamps = nil
+}
+
+func (this *averagedPerceptron) save(file string, header string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+
+	fmt.Fprintf(w, "## %s\n<Labels>\n", header)
+	for _, l := range this.labels {
+		fmt.Fprintln(w, l)
+	}
+	fmt.Fprintln(w, "</Labels>\n<Weights>")
+
+	features := make([]string, 0, len(this.weights))
+	for f := range this.weights {
+		features = append(features, f)
+	}
+	sort.Strings(features)
+	for _, feat := range features {
+		labels := make([]string, 0, len(this.weights[feat]))
+		for l := range this.weights[feat] {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			fmt.Fprintf(w, "%s %s %g\n", feat, l, this.weights[feat][l])
+		}
+	}
+	fmt.Fprintln(w, "</Weights>")
+
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func loadAveragedPerceptron(file string, module int64) (*averagedPerceptron, error) {
+	this := newAveragedPerceptron(nil)
+	this.totals = nil
+	this.stamps = nil
+
+	cfg := NewConfigFile(false, "##")
+	cfg.AddSection("Labels", PERCEPTRON_LABELS)
+	cfg.AddSection("Weights", PERCEPTRON_WEIGHTS)
+	cfg.module = module
+
+	if err := cfg.Open(file); err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]bool)
+	line := ""
+	for cfg.GetContentLine(&line) {
+		items := strings.Fields(line)
+		switch cfg.GetSection() {
+		case PERCEPTRON_LABELS:
+			{
+				if err := cfg.CheckFields(items, 1); err != nil {
+					return nil, err
+				}
+				this.labels = append(this.labels, items[0])
+				known[items[0]] = true
+				break
+			}
+		case PERCEPTRON_WEIGHTS:
+			{
+				if err := cfg.CheckFields(items, 3); err != nil {
+					return nil, err
+				}
+				if !known[items[1]] {
+					return nil, cfg.Errorf("unknown label %q", items[1])
+				}
+				w, err := strconv.ParseFloat(items[2], 64)
+				if err != nil {
+					return nil, cfg.Errorf("invalid weight %q", items[2])
+				}
+				if this.weights[items[0]] == nil {
+					this.weights[items[0]] = make(map[string]float64)
+				}
+				this.weights[items[0]][items[1]] = w
+				break
+			}
+		default:
+			break
+		}
+	}
+
+	if len(this.labels) == 0 {
+		return nil, newConfigError(module, file, 0, "no labels found")
+	}
+	return this, nil
+}
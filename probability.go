@@ -3,6 +3,7 @@ package linguo
 import (
 	"container/list"
 	set "gopkg.in/fatih/set.v0"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -36,7 +37,7 @@ type Probability struct {
 	longSuff              int
 }
 
-func NewProbability(probFile string, Threashold float64) *Probability {
+func NewProbability(probFile string, Threashold float64) (*Probability, error) {
 	this := Probability{
 		singleTags:  make(map[string]float64),
 		classTags:   make(map[string]map[string]float64),
@@ -67,9 +68,10 @@ func NewProbability(probFile string, Threashold float64) *Probability {
 	cfg.AddSection("LidstoneLambdaLexical", PROBABILITY_LAMBDA_LEX)
 	cfg.AddSection("LidstoneLambdaClass", PROBABILITY_LAMBDA_CLASS)
 	cfg.AddSection("TagsetFile", PROBABILITY_TAGSET)
+	cfg.module = MOD_PROBABILITY
 
-	if !cfg.Open(probFile) {
-		CRASH("Error opening file "+probFile, MOD_PROBABILITY)
+	if err := cfg.Open(probFile); err != nil {
+		return nil, err
 	}
 
 	sumUnk = 0
@@ -81,6 +83,9 @@ func NewProbability(probFile string, Threashold float64) *Probability {
 		switch cfg.GetSection() {
 		case PROBABILITY_SINGLE_TAG:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				key = items[0]
 				frq = items[1]
 				probab, _ = strconv.ParseFloat(frq, 64)
@@ -117,6 +122,9 @@ func NewProbability(probFile string, Threashold float64) *Probability {
 			}
 		case PROBABILITY_UNKNOWN:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				key = items[0]
 				frq = items[1]
 				probab, _ = strconv.ParseFloat(frq, 64)
@@ -132,6 +140,9 @@ func NewProbability(probFile string, Threashold float64) *Probability {
 			}
 		case PROBABILITY_SUFFIXES:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				tmpMap = make(map[string]float64)
 				key = items[0]
 				frq1 := items[1]
@@ -183,12 +194,20 @@ func NewProbability(probFile string, Threashold float64) *Probability {
 		this.singleTags[k] /= sumSing
 	}
 
+	if ftags == "" {
+		return nil, newConfigError(MOD_PROBABILITY, probFile, 0, "missing <TagsetFile> section")
+	}
+
 	path := probFile[0:strings.LastIndex(probFile, "/")]
-	this.Tags = NewTagset(path + "/" + strings.Replace(ftags, "./", "", -1))
+	tags, err := NewTagset(path + "/" + strings.Replace(ftags, "./", "", -1))
+	if err != nil {
+		return nil, err
+	}
+	this.Tags = tags
 
 	TRACE(3, "analyzer succesfully created", MOD_PROBABILITY)
 
-	return &this
+	return &this, nil
 }
 
 func (this *Probability) Analyze(se *Sentence) {
@@ -204,7 +223,13 @@ func (this *Probability) AnnotateWord(w *Word) {
 
 	TRACE(2, "--Assigning probabilities to: "+w.getForm(), MOD_PROBABILITY)
 
-	if na > 0 && (w.foundInDict() || strings.HasPrefix(w.getTag(0), "F") || strings.HasPrefix(w.getTag(0), "Z") || w.hasRetokenizable()) {
+	if w.isLocked() {
+		// forced analyses, as the ones of the user map, are neither smoothed
+		// nor guessed: they share the mass and keep their order
+		for li := w.Front(); li != nil; li = li.Next() {
+			li.Value.(*Analysis).setProb(1.0 / float64(na))
+		}
+	} else if na > 0 && (w.foundInDict() || strings.HasPrefix(w.getTag(0), "F") || strings.HasPrefix(w.getTag(0), "Z") || w.getTag(0) == DATES_TAG || w.hasRetokenizable()) {
 		//TRACE(2, "Form with analysis. Found in dict (" + )
 		this.smoothing(w)
 		sum = 1
@@ -266,7 +291,13 @@ func (this *Probability) smoothing(w *Word) {
 		usingBackoff = true
 		c := ""
 		cNP := ""
-		for k, _ := range tagShorts {
+		// ambiguity classes list their tags sorted, as FreeLing writes them
+		shorts := make([]string, 0, len(tagShorts))
+		for k := range tagShorts {
+			shorts = append(shorts, k)
+		}
+		sort.Strings(shorts)
+		for _, k := range shorts {
 			cNP += "-" + k
 			if k != "NP" {
 				c += "-" + k
@@ -370,8 +401,17 @@ func (this *Probability) guesser(w *Word, mass float64) float64 {
 		stags.Add(this.Tags.GetShortTag(li.Value.(*Analysis).getTag()))
 	}
 
+	// in order, so that tags with the same probability always come out in the
+	// same order
+	unkTags := make([]string, 0, len(this.unkTags))
+	for k := range this.unkTags {
+		unkTags = append(unkTags, k)
+	}
+	sort.Strings(unkTags)
+
 	la := list.New()
-	for k, v := range this.unkTags {
+	for _, k := range unkTags {
+		v := this.unkTags[k]
 		TRACE(2, "   guesser checking tag "+k, MOD_PROBABILITY)
 		hasit := stags.Has(this.Tags.GetShortTag(k))
 
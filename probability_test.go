@@ -0,0 +1,76 @@
+package linguo
+
+import (
+	"strings"
+	"testing"
+)
+
+// analysesString writes the analyses of w as lemma/tag.
+func analysesString(w *Word) string {
+	var out []string
+	for a := w.Front(); a != nil; a = a.Next() {
+		out = append(out, a.Value.(*Analysis).getLemma()+"/"+a.Value.(*Analysis).getTag())
+	}
+	return strings.Join(out, " ")
+}
+
+func newTestProbability(t *testing.T) *Probability {
+	t.Helper()
+	prob, err := NewProbability("testdata/en/probabilitats.dat", 0.001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return prob
+}
+
+// TestDatesNotGuessed checks that a date keeps its only W analysis, even when
+// it was not found in the dictionary, instead of getting the ones of the
+// guesser.
+func TestDatesNotGuessed(t *testing.T) {
+	prob := newTestProbability(t)
+
+	w := NewWordFromLemma("March_3rd_,_2017")
+	w.setAnalysis(NewAnalysis("[??:3/3/2017:??.??:??]", DATES_TAG))
+	w.setFoundInDict(false)
+	prob.AnnotateWord(w)
+
+	if got, want := analysesString(w), "[??:3/3/2017:??.??:??]/W"; got != want {
+		t.Errorf("got analyses %s, want %s", got, want)
+	}
+	if p := w.Front().Value.(*Analysis).getProb(); p != 1 {
+		t.Errorf("got probability %v, want 1", p)
+	}
+}
+
+// TestLockedNotGuessed checks that forced analyses are kept as given.
+func TestLockedNotGuessed(t *testing.T) {
+	prob := newTestProbability(t)
+
+	for _, analyses := range [][][2]string{
+		{{"#linguo", "NP00000"}},
+		{{"ACME-123", "NNP"}, {"acme", "NN"}},
+	} {
+		w := NewWordFromLemma(analyses[0][0])
+		var want []string
+		for k, a := range analyses {
+			if k == 0 {
+				w.setAnalysis(NewAnalysis(a[0], a[1]))
+			} else {
+				w.addAnalysis(NewAnalysis(a[0], a[1]))
+			}
+			want = append(want, a[0]+"/"+a[1])
+		}
+		w.setFoundInDict(false)
+		w.lockAnalysis()
+		prob.AnnotateWord(w)
+
+		if got := analysesString(w); got != strings.Join(want, " ") {
+			t.Errorf("%s: got analyses %s, want %s", w.getForm(), got, strings.Join(want, " "))
+		}
+		for a := w.Front(); a != nil; a = a.Next() {
+			if p := a.Value.(*Analysis).getProb(); p != 1/float64(len(analyses)) {
+				t.Errorf("%s: got probability %v, want %v", w.getForm(), p, 1/float64(len(analyses)))
+			}
+		}
+	}
+}
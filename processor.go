@@ -0,0 +1,224 @@
+package linguo
+
+import (
+	"context"
+	"fmt"
+)
+
+// Names of the built-in pipeline stages, in their default order.
+const (
+	STAGE_MORFO  = "morfo"
+	STAGE_SENSE  = "sense"
+	STAGE_TAGGER = "tagger"
+	STAGE_NEC    = "nec"
+	STAGE_PARSER = "parser"
+	STAGE_DSB    = "dsb"
+)
+
+// Processor is a pipeline stage that works on one sentence at a time, such as
+// Maco, HMMTagger or ChartParser. Processors that can be interrupted may also
+// implement ContextProcessor.
+type Processor interface {
+	Analyze(s *Sentence)
+}
+
+type ContextProcessor interface {
+	Processor
+	AnalyzeContext(ctx context.Context, s *Sentence) error
+}
+
+// DocumentProcessor is a pipeline stage that needs every sentence of the
+// document at once, such as UKB. Processors that can be interrupted may also
+// implement ContextDocumentProcessor.
+type DocumentProcessor interface {
+	Analyze(sentences []*Sentence)
+}
+
+type ContextDocumentProcessor interface {
+	DocumentProcessor
+	AnalyzeContext(ctx context.Context, sentences []*Sentence) error
+}
+
+// Stage is a named step of the pipeline. Exactly one of Processor and
+// DocumentProcessor is set.
+type Stage struct {
+	Name              string
+	Processor         Processor
+	DocumentProcessor DocumentProcessor
+}
+
+// optionsProcessor is implemented by the built-in stages whose behaviour can
+// be changed for a single call through WorkflowOptions.
+type optionsProcessor interface {
+	analyzeWithOptions(ctx context.Context, s *Sentence, opts *WorkflowOptions) error
+}
+
+func (s Stage) analyzeSentence(ctx context.Context, sentence *Sentence, opts *WorkflowOptions) error {
+	if s.Processor == nil {
+		return s.analyzeDocument(ctx, []*Sentence{sentence})
+	}
+	if p, ok := s.Processor.(optionsProcessor); ok {
+		return p.analyzeWithOptions(ctx, sentence, opts)
+	}
+	if p, ok := s.Processor.(ContextProcessor); ok {
+		return p.AnalyzeContext(ctx, sentence)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.Processor.Analyze(sentence)
+	return nil
+}
+
+func (s Stage) analyzeDocument(ctx context.Context, sentences []*Sentence) error {
+	if p, ok := s.DocumentProcessor.(ContextDocumentProcessor); ok {
+		return p.AnalyzeContext(ctx, sentences)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.DocumentProcessor.Analyze(sentences)
+	return nil
+}
+
+// buildStages returns the stages the engine runs, in order: the built-in
+// modules that were loaded followed by the custom processors in registration
+// order, unless NLPOptions.Stages gives an explicit order. Disabled stages are
+// then removed.
+func (e *NLPEngine) buildStages() ([]Stage, error) {
+	available := make([]Stage, 0)
+	if e.morfo != nil {
+		available = append(available, Stage{Name: STAGE_MORFO, Processor: e.morfo})
+	}
+	if e.timex != nil {
+		available = append(available, Stage{Name: STAGE_TIMEX, Processor: e.timex})
+	}
+	if e.sense != nil {
+		available = append(available, Stage{Name: STAGE_SENSE, Processor: e.sense})
+	}
+	if e.tagger != nil {
+		available = append(available, Stage{Name: STAGE_TAGGER, Processor: e.tagger})
+	}
+	if e.nec != nil {
+		available = append(available, Stage{Name: STAGE_NEC, Processor: e.nec})
+	}
+	if e.shallowParser != nil {
+		available = append(available, Stage{Name: STAGE_PARSER, Processor: e.shallowParser})
+	}
+	if e.dsb != nil {
+		available = append(available, Stage{Name: STAGE_DSB, DocumentProcessor: e.dsb})
+	}
+
+	builtin := map[string]bool{STAGE_MORFO: true, STAGE_TIMEX: true, STAGE_SENSE: true, STAGE_TAGGER: true, STAGE_NEC: true, STAGE_PARSER: true, STAGE_DSB: true}
+	byName := make(map[string]Stage)
+	for _, s := range available {
+		byName[s.Name] = s
+	}
+	for _, s := range e.options.Processors {
+		if s.Name == "" || builtin[s.Name] {
+			return nil, newConfigError(MOD_CONFIG, "", 0, "invalid processor name %q", s.Name)
+		}
+		if _, ok := byName[s.Name]; ok {
+			return nil, newConfigError(MOD_CONFIG, "", 0, "processor %q registered twice", s.Name)
+		}
+		if (s.Processor == nil) == (s.DocumentProcessor == nil) {
+			return nil, newConfigError(MOD_CONFIG, "", 0, "processor %q must be a sentence or a document processor", s.Name)
+		}
+		byName[s.Name] = s
+		available = append(available, s)
+	}
+
+	stages := available
+	if e.options.Stages != nil {
+		stages = make([]Stage, 0, len(e.options.Stages))
+		seen := make(map[string]bool)
+		for _, name := range e.options.Stages {
+			s, ok := byName[name]
+			if !ok {
+				if builtin[name] {
+					return nil, newConfigError(MOD_CONFIG, "", 0, "stage %q is not loaded", name)
+				}
+				return nil, newConfigError(MOD_CONFIG, "", 0, "unknown stage %q", name)
+			}
+			if seen[name] {
+				return nil, newConfigError(MOD_CONFIG, "", 0, "stage %q listed twice", name)
+			}
+			seen[name] = true
+			stages = append(stages, s)
+		}
+	}
+
+	disabled := make(map[string]bool)
+	for _, name := range e.options.DisabledStages {
+		if _, ok := byName[name]; !ok && !builtin[name] {
+			return nil, newConfigError(MOD_CONFIG, "", 0, "unknown stage %q", name)
+		}
+		disabled[name] = true
+	}
+	out := make([]Stage, 0, len(stages))
+	for _, s := range stages {
+		if !disabled[s.Name] {
+			out = append(out, s)
+		}
+	}
+	if name := stageWithoutMorfo(out); name != "" {
+		return nil, newConfigError(MOD_CONFIG, "", 0, "stage %q needs the %q stage before it", name, STAGE_MORFO)
+	}
+	return out, nil
+}
+
+// stagesNeedingMorfo are the built-in stages that work on the analyses made
+// by the morfo stage.
+var stagesNeedingMorfo = map[string]bool{STAGE_TAGGER: true, STAGE_NEC: true, STAGE_PARSER: true}
+
+// stageWithoutMorfo returns the first of stages that needs the morfo stage
+// and does not have it before, or "" when there is none.
+func stageWithoutMorfo(stages []Stage) string {
+	morfo := false
+	for _, s := range stages {
+		if s.Name == STAGE_MORFO {
+			morfo = true
+		} else if stagesNeedingMorfo[s.Name] && !morfo {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// selectStages returns the engine stages named in names, in engine order, or
+// all of them when names is nil. The tagger, NEC and parser stages can only
+// be selected together with morfo.
+func (e *NLPEngine) selectStages(names []string) ([]Stage, error) {
+	if names == nil {
+		return e.stages, nil
+	}
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[name] = true
+	}
+	stages := make([]Stage, 0, len(names))
+	for _, s := range e.stages {
+		if wanted[s.Name] {
+			stages = append(stages, s)
+			delete(wanted, s.Name)
+		}
+	}
+	for _, name := range names {
+		if wanted[name] {
+			return nil, fmt.Errorf("stage %q is not run by this engine", name)
+		}
+	}
+	if name := stageWithoutMorfo(stages); name != "" {
+		return nil, fmt.Errorf("stage %q needs the %q stage before it", name, STAGE_MORFO)
+	}
+	return stages, nil
+}
+
+// Stages returns the names of the stages run by the engine, in order.
+func (e *NLPEngine) Stages() []string {
+	names := make([]string, len(e.stages))
+	for i, s := range e.stages {
+		names[i] = s.Name
+	}
+	return names
+}
@@ -0,0 +1,43 @@
+package linguo
+
+import (
+	"context"
+	"testing"
+)
+
+func TestOnlyStagesNeedsMorfo(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	ctx := context.Background()
+
+	if _, err := e.WorkflowWithOptions(ctx, "The robot was created.", NewWorkflowOptions().OnlyStages(STAGE_TAGGER)); err == nil {
+		t.Errorf("tagger without morfo: no error")
+	}
+	r, err := e.WorkflowWithOptions(ctx, "The robot was created.", NewWorkflowOptions().OnlyStages(STAGE_MORFO, STAGE_TAGGER))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Sentences[0].Tokens[1].Pos; got != "NN" {
+		t.Errorf("robot tagged %s, want NN", got)
+	}
+}
+
+func TestStagesNeedMorfo(t *testing.T) {
+	o := NewNLPOptions("testdata", "en").
+		TokenizerFilePath("/tokenizer.dat").
+		SplitterFilePath("/splitter.dat").
+		TaggerFilePath("/tagger.dat").
+		WithMorfoOptions(newTestMacoOptions()).
+		DisableStages(STAGE_MORFO)
+	if _, err := NewNLPEngine(o); err == nil {
+		t.Errorf("tagger without morfo: no error")
+	}
+}
+
+func TestTaggerWithoutAnalyses(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	s := NewSentence()
+	s.PushBack(NewWordFromLemma("robot"))
+	if err := e.tagger.AnalyzeContext(context.Background(), s); err == nil {
+		t.Errorf("tagging words with no analyses: no error")
+	}
+}
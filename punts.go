@@ -4,6 +4,7 @@ import (
 	"container/list"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 const PUNTS_OTHER = "<Other>"
@@ -13,12 +14,15 @@ type Punts struct {
 	*Database
 }
 
-func NewPunts(puntFile string) *Punts {
-	this := Punts{}
-	this.Database = NewDatabaseFromFile(puntFile)
+func NewPunts(puntFile string) (*Punts, error) {
+	db, err := NewDatabaseFromFile(puntFile)
+	if err != nil {
+		return nil, err
+	}
+	this := Punts{Database: db}
 	this.tagOthers = this.accessDatabase(PUNTS_OTHER)
 
-	return &this
+	return &this, nil
 }
 
 func (this *Punts) analyze(se *Sentence) {
@@ -26,6 +30,9 @@ func (this *Punts) analyze(se *Sentence) {
 	var i *list.Element
 
 	for i = se.Front(); i != nil; i = i.Next() {
+		if i.Value.(*Word).isLocked() {
+			continue
+		}
 		form = i.Value.(*Word).getForm()
 		TRACE(3, "Checking form "+form, MOD_PUNTS)
 		data := this.accessDatabase(form)
@@ -37,7 +44,8 @@ func (this *Punts) analyze(se *Sentence) {
 			i.Value.(*Word).lockAnalysis()
 		} else {
 			TRACE(3, "   ["+form+"] not found in map: known punctuation", MOD_PUNTS)
-			if !(unicode.IsNumber(rune(form[0])) || unicode.IsLetter(rune(form[0]))) {
+			first, _ := utf8.DecodeRuneInString(form)
+			if !(unicode.IsNumber(first) || unicode.IsLetter(first)) {
 				TRACE(3, "   ["+form+"] no alphanumeric char found. tag as "+this.tagOthers, MOD_PUNTS)
 				i.Value.(*Word).setAnalysis(NewAnalysis(form, this.tagOthers))
 			}
@@ -0,0 +1,268 @@
+package linguo
+
+import (
+	"container/list"
+	"regexp"
+	"strings"
+)
+
+const (
+	QUANTITIES_CURRENCY = 1 + iota
+	QUANTITIES_MEASURE
+	QUANTITIES_PERCENTAGE
+	QUANTITIES_RATIO
+)
+
+const (
+	QUANTITIES_CURRENCY_TAG   = "Zm"
+	QUANTITIES_MEASURE_TAG    = "Zu"
+	QUANTITIES_PERCENTAGE_TAG = "Zp"
+)
+
+// quantityUnit is a unit of the quantities file, with the section it was
+// given in.
+type quantityUnit struct {
+	kind int
+	code string
+}
+
+// Quantities joins an amount and its unit in a multiword: currencies
+// ("$ 3.5 million", "30 euros") tagged Zm with lemma USD:3500000, measures
+// ("25 kg", "2.5km/h") tagged Zu with lemma kg:25, percentages ("30 %", "30
+// per cent") tagged Zp with lemma 30/100 and ratios ("3 out of 4", "3:1")
+// tagged Zp with lemma 3/4. Amounts are the numbers found by the numbers
+// module, or numbers written with digits.
+//
+// The units are read from a file with the sections Currency and Measure, each
+// line holding a code followed by its forms, and Percentage and Ratio, each
+// line holding forms. Forms of several words are written joined by "_":
+//
+//	<Currency>
+//	USD $ US_$ dollar dollars
+//	</Currency>
+//	<Measure>
+//	kg kg kilogram kilograms
+//	</Measure>
+//	<Percentage>
+//	% percent per_cent
+//	</Percentage>
+//	<Ratio>
+//	out_of of_every
+//	</Ratio>
+type Quantities struct {
+	units    map[string]quantityUnit
+	maxWords int
+	numb     *Numbers
+	ratio    *regexp.Regexp
+}
+
+// NewQuantities loads the units in quantFile. numb reads the amounts written
+// with digits.
+func NewQuantities(quantFile string, numb *Numbers) (*Quantities, error) {
+	this := Quantities{
+		units: make(map[string]quantityUnit),
+		numb:  numb,
+		ratio: regexp.MustCompile(`^(\d+):(\d+)$`),
+	}
+
+	cfg := NewConfigFile(false, "##")
+	cfg.AddSection("Currency", QUANTITIES_CURRENCY)
+	cfg.AddSection("Measure", QUANTITIES_MEASURE)
+	cfg.AddSection("Percentage", QUANTITIES_PERCENTAGE)
+	cfg.AddSection("Ratio", QUANTITIES_RATIO)
+	cfg.module = MOD_QUANTITIES
+
+	if err := cfg.Open(quantFile); err != nil {
+		return nil, err
+	}
+
+	line := ""
+
+	for cfg.GetContentLine(&line) {
+		items := Split(line, " ")
+		switch cfg.GetSection() {
+		case QUANTITIES_CURRENCY, QUANTITIES_MEASURE:
+			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
+				for _, form := range items[1:] {
+					if !this.addUnit(form, quantityUnit{kind: cfg.GetSection(), code: items[0]}) {
+						return nil, cfg.Errorf("unit form '%s' given twice", form)
+					}
+				}
+				break
+			}
+		case QUANTITIES_PERCENTAGE, QUANTITIES_RATIO:
+			{
+				if err := cfg.CheckFields(items, 1); err != nil {
+					return nil, err
+				}
+				for _, form := range items {
+					if !this.addUnit(form, quantityUnit{kind: cfg.GetSection()}) {
+						return nil, cfg.Errorf("unit form '%s' given twice", form)
+					}
+				}
+				break
+			}
+		default:
+			break
+		}
+	}
+
+	TRACE(1, "Module created successfully", MOD_QUANTITIES)
+
+	return &this, nil
+}
+
+// addUnit adds a form of a unit, unless it is already known.
+func (this *Quantities) addUnit(form string, u quantityUnit) bool {
+	form = strings.ToLower(form)
+	if _, ok := this.units[form]; ok {
+		return false
+	}
+	this.units[form] = u
+	if n := len(strings.Split(form, "_")); n > this.maxWords {
+		this.maxWords = n
+	}
+	return true
+}
+
+// unit returns the longest unit starting at words[i] and the number of words
+// it takes, 0 when there is none.
+func (this *Quantities) unit(words []*list.Element, i int) (quantityUnit, int) {
+	for n := this.maxWords; n > 0; n-- {
+		if i+n > len(words) {
+			continue
+		}
+		forms := make([]string, n)
+		for k := 0; k < n; k++ {
+			forms[k] = words[i+k].Value.(*Word).getLCForm()
+		}
+		if u, ok := this.units[strings.Join(forms, "_")]; ok {
+			return u, n
+		}
+	}
+	return quantityUnit{}, 0
+}
+
+// value returns the amount a word stands for. Words whose analyses are locked,
+// as the ones of the user map, are no amounts.
+func (this *Quantities) value(w *Word) (string, bool) {
+	if w.isLocked() {
+		return "", false
+	}
+	for a := w.Front(); a != nil; a = a.Next() {
+		if a.Value.(*Analysis).getTag() == NUMBERS_CARDINAL_TAG {
+			return a.Value.(*Analysis).getLemma(), true
+		}
+	}
+	if w.getNAnalysis() == 0 {
+		if v, ok := this.numb.digitValue(w.getLCForm()); ok {
+			return numberLemma(v), true
+		}
+	}
+	return "", false
+}
+
+// match returns the index of the last word of the quantity starting at
+// words[i], with its lemma and tag, or -1 when there is none.
+func (this *Quantities) match(words []*list.Element, i int) (int, string, string) {
+	w := words[i].Value.(*Word)
+
+	// currency before the amount
+	if u, n := this.unit(words, i); n > 0 && u.kind == QUANTITIES_CURRENCY && i+n < len(words) {
+		if v, ok := this.value(words[i+n].Value.(*Word)); ok {
+			return i + n, u.code + ":" + v, QUANTITIES_CURRENCY_TAG
+		}
+	}
+
+	v, ok := this.value(w)
+	if !ok {
+		if w.getNAnalysis() == 0 {
+			if lemma, tag := this.glued(w.getLCForm()); lemma != "" {
+				return i, lemma, tag
+			}
+		}
+		return -1, "", ""
+	}
+
+	u, n := this.unit(words, i+1)
+	if n == 0 {
+		return -1, "", ""
+	}
+	end := i + n
+	switch u.kind {
+	case QUANTITIES_CURRENCY:
+		return end, u.code + ":" + v, QUANTITIES_CURRENCY_TAG
+	case QUANTITIES_MEASURE:
+		return end, u.code + ":" + v, QUANTITIES_MEASURE_TAG
+	case QUANTITIES_PERCENTAGE:
+		return end, v + "/100", QUANTITIES_PERCENTAGE_TAG
+	case QUANTITIES_RATIO:
+		if end+1 < len(words) {
+			if d, ok := this.value(words[end+1].Value.(*Word)); ok {
+				return end + 1, v + "/" + d, QUANTITIES_PERCENTAGE_TAG
+			}
+		}
+	}
+	return -1, "", ""
+}
+
+// glued reads a quantity written as a single token, as "25kg" or "3:1".
+func (this *Quantities) glued(form string) (string, string) {
+	if m := this.ratio.FindStringSubmatch(form); m != nil {
+		return m[1] + "/" + m[2], QUANTITIES_PERCENTAGE_TAG
+	}
+	for k := len(form) - 1; k > 0; k-- {
+		v, ok := this.numb.digitValue(form[:k])
+		if !ok {
+			continue
+		}
+		u, ok := this.units[form[k:]]
+		if !ok {
+			return "", ""
+		}
+		switch u.kind {
+		case QUANTITIES_CURRENCY:
+			return u.code + ":" + numberLemma(v), QUANTITIES_CURRENCY_TAG
+		case QUANTITIES_MEASURE:
+			return u.code + ":" + numberLemma(v), QUANTITIES_MEASURE_TAG
+		case QUANTITIES_PERCENTAGE:
+			return numberLemma(v) + "/100", QUANTITIES_PERCENTAGE_TAG
+		}
+		return "", ""
+	}
+	return "", ""
+}
+
+func (this *Quantities) Analyze(se *Sentence) {
+	this.analyze(se)
+}
+
+func (this *Quantities) analyze(se *Sentence) {
+	words := make([]*list.Element, 0, se.Len())
+	for i := se.Front(); i != nil; i = i.Next() {
+		words = append(words, i)
+	}
+
+	found := false
+	for i := 0; i < len(words); i++ {
+		end, lemma, tag := this.match(words, i)
+		if end < 0 {
+			continue
+		}
+
+		w := words[i].Value.(*Word)
+		if end > i {
+			w = se.joinWords(words[i], words[end])
+			found = true
+		}
+		w.setAnalysis(NewAnalysis(lemma, tag))
+		TRACE(3, "Found quantity "+w.getForm()+" ("+lemma+")", MOD_QUANTITIES)
+		i = end
+	}
+	if found {
+		se.rebuildWordIndex()
+	}
+}
@@ -0,0 +1,43 @@
+package linguo
+
+import (
+	"strings"
+	"testing"
+)
+
+// tokensString writes the tokens of r as form/lemma.
+func tokensString(r Result) string {
+	var out []string
+	for _, s := range r.Sentences {
+		for _, t := range s.Tokens {
+			out = append(out, t.Base+"/"+t.Lemma)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func TestQuantitiesUserMap(t *testing.T) {
+	mo := newTestMacoOptions().
+		QuantitiesFilePath("/en/quantities.dat").
+		UserMapFilePath("/en/usermap.dat")
+	e := newTestEngine(t, mo)
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"The dog weighs 25 kg.", []string{"25_kg/kg:25"}},
+		{"It costs $ 30 today.", []string{"$_30/USD:30"}},
+		// the user map tags 10x20 as Z, which is no amount
+		{"The box is 10x20 kg heavy.", []string{"10x20/10x20", "kg/kg"}},
+		{"It costs $ 10x20 today.", []string{"$/$", "10x20/10x20"}},
+	}
+	for _, tt := range tests {
+		got := tokensString(e.Workflow(tt.input))
+		for _, want := range tt.want {
+			if !strings.Contains(" "+got+" ", " "+want+" ") {
+				t.Errorf("%q: got %s, want %s", tt.input, got, want)
+			}
+		}
+	}
+}
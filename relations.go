@@ -0,0 +1,34 @@
+package linguo
+
+import "github.com/ruggi/linguo/models"
+
+// RelationExtractor finds binary relations between the entity mentions of
+// each sentence. It is run by NLPEngine after entity extraction, with the
+// mentions aligned to the sentence tokens. Implementations must be safe for
+// concurrent use.
+type RelationExtractor interface {
+	Extract(sentences []*Sentence, entities []*models.Entity) []*models.Relation
+	Release()
+}
+
+// relationTokens returns the words of s as a flat token list, with multiwords
+// split into their components, and for every word the index of its first
+// token and the number of tokens it spans.
+func relationTokens(s *Sentence) ([]string, []int, []int) {
+	var tokens []string
+	words := s.Words()
+	first := make([]int, len(words))
+	count := make([]int, len(words))
+	for i, w := range words {
+		first[i] = len(tokens)
+		if w.isMultiword() {
+			for c := w.multiword.Front(); c != nil; c = c.Next() {
+				tokens = append(tokens, c.Value.(*Word).getForm())
+			}
+		} else {
+			tokens = append(tokens, w.getForm())
+		}
+		count[i] = len(tokens) - first[i]
+	}
+	return tokens, first, count
+}
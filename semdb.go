@@ -75,7 +75,7 @@ type SemanticDB struct {
 	wndb     *Database
 }
 
-func NewSemanticDB(wsdFile string) *SemanticDB {
+func NewSemanticDB(wsdFile string) (*SemanticDB, error) {
 	this := SemanticDB{
 		posMap: list.New(),
 	}
@@ -87,9 +87,10 @@ func NewSemanticDB(wsdFile string) *SemanticDB {
 	cfg := NewConfigFile(true, "")
 	cfg.AddSection("WNposMap", SEMDB_WN_POS_MAP)
 	cfg.AddSection("DataFiles", SEMDB_DATA_FILES)
+	cfg.module = MOD_SEMDB
 
-	if !cfg.Open(wsdFile) {
-		panic("Error opening configuration file " + wsdFile)
+	if err := cfg.Open(wsdFile); err != nil {
+		return nil, err
 	}
 
 	line := ""
@@ -99,6 +100,9 @@ func NewSemanticDB(wsdFile string) *SemanticDB {
 		switch cfg.GetSection() {
 		case SEMDB_WN_POS_MAP:
 			{
+				if err := cfg.CheckFields(items, 3); err != nil {
+					return nil, err
+				}
 				r := PosMapRule{}
 				r.pos = items[0]
 				r.wnpos = items[1]
@@ -111,6 +115,9 @@ func NewSemanticDB(wsdFile string) *SemanticDB {
 			}
 		case SEMDB_DATA_FILES:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				key := items[0]
 				fname := items[1]
 				if key == "formDictFile" {
@@ -132,14 +139,14 @@ func NewSemanticDB(wsdFile string) *SemanticDB {
 	} else {
 		fileString, err := ioutil.ReadFile(formFile)
 		if err != nil {
-			panic("Error loading file " + formFile)
+			return nil, openError(MOD_SEMDB, formFile, err)
 		}
 		lines := strings.Split(string(fileString), "\n")
 		this.formDict = NewDatabase(DB_MAP)
 		for _, line := range lines {
 			items := Split(line, " ")
 			form := items[0]
-			for i := 1; i < len(items); i = i + 2 {
+			for i := 1; i < len(items)-1; i = i + 2 {
 				lemma := items[i]
 				tag := items[i+1]
 				if posset.Has(tag) {
@@ -154,7 +161,7 @@ func NewSemanticDB(wsdFile string) *SemanticDB {
 	} else {
 		fileString, err := ioutil.ReadFile(dictFile)
 		if err != nil {
-			panic("Error loading file " + dictFile)
+			return nil, openError(MOD_SEMDB, dictFile, err)
 		}
 		lines := strings.Split(string(fileString), "\n")
 		this.senseDB = NewDatabase(DB_MAP)
@@ -173,10 +180,14 @@ func NewSemanticDB(wsdFile string) *SemanticDB {
 	if wnFile == "" {
 		this.wndb = nil
 	} else {
-		this.wndb = NewDatabaseFromFile(wnFile)
+		wndb, err := NewDatabaseFromFile(wnFile)
+		if err != nil {
+			return nil, err
+		}
+		this.wndb = wndb
 	}
 
-	return &this
+	return &this, nil
 }
 
 func (this *SemanticDB) getWordSenses(form string, lemma string, pos string) *list.List {
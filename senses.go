@@ -9,16 +9,21 @@ type Senses struct {
 	semdb     *SemanticDB
 }
 
-func NewSenses(wsdFile string) *Senses {
+func NewSenses(wsdFile string) (*Senses, error) {
+	semdb, err := NewSemanticDB(wsdFile)
+	if err != nil {
+		return nil, err
+	}
 	this := Senses{
-		semdb: NewSemanticDB(wsdFile),
+		semdb: semdb,
 	}
 
 	cfg := NewConfigFile(true, "")
 	cfg.AddSection("DuplicateAnalysis", SENSES_DUP_ANALYSIS)
+	cfg.module = MOD_SENSES
 
-	if !cfg.Open(wsdFile) {
-		panic("Error opening file " + wsdFile)
+	if err := cfg.Open(wsdFile); err != nil {
+		return nil, err
 	}
 
 	line := ""
@@ -38,7 +43,7 @@ func NewSenses(wsdFile string) *Senses {
 		}
 	}
 
-	return &this
+	return &this, nil
 }
 
 func (this *Senses) Analyze(sentence *Sentence) {
@@ -3,7 +3,6 @@ package linguo
 import (
 	"container/list"
 	"strconv"
-	"strings"
 
 	set "gopkg.in/fatih/set.v0"
 )
@@ -26,7 +25,7 @@ type Splitter struct {
 	markers                   map[string]int
 }
 
-func NewSplitter(splitterFile string) *Splitter {
+func NewSplitter(splitterFile string) (*Splitter, error) {
 	s := Splitter{
 		starters: set.New(),
 		enders:   make(map[string]bool),
@@ -38,9 +37,10 @@ func NewSplitter(splitterFile string) *Splitter {
 	cfg.AddSection("Markers", SPLITTER_MARKERS)
 	cfg.AddSection("SentenceEnd", SPLITTER_SENT_END)
 	cfg.AddSection("SentenceStart", SPLITTER_SENT_START)
+	cfg.module = MOD_SPLITTER
 
-	if !cfg.Open(splitterFile) {
-		CRASH("Error opening file "+splitterFile, MOD_SPLITTER)
+	if err := cfg.Open(splitterFile); err != nil {
+		return nil, err
 	}
 
 	s.SPLIT_AllowBetweenMarkers = true
@@ -54,18 +54,24 @@ func NewSplitter(splitterFile string) *Splitter {
 		switch cfg.GetSection() {
 		case SPLITTER_GENERAL:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				name := items[0]
 				if name == "AllowBetweenMarkers" {
 					s.SPLIT_AllowBetweenMarkers, _ = strconv.ParseBool(items[1])
 				} else if name == "MaxWords" {
 					s.SPLIT_MaxWords, _ = strconv.ParseInt(items[1], 10, 64)
 				} else {
-					panic("Unexpected splitter option " + name)
+					return nil, cfg.Errorf("unexpected splitter option %s", name)
 				}
 				break
 			}
 		case SPLITTER_MARKERS:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				open := items[0]
 				close := items[1]
 				if open != close {
@@ -80,6 +86,9 @@ func NewSplitter(splitterFile string) *Splitter {
 			}
 		case SPLITTER_SENT_END:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				name := items[0]
 				value, _ := strconv.ParseBool(items[1])
 				s.enders[name] = !value
@@ -95,7 +104,7 @@ func NewSplitter(splitterFile string) *Splitter {
 		}
 	}
 
-	return &s
+	return &s, nil
 }
 
 type SplitterStatus struct {
@@ -124,6 +133,16 @@ func (s *Splitter) CloseSession(ses *SplitterStatus) {
 	ses = nil
 }
 
+// resetSession clears st after a sentence has been emitted, keeping the
+// sentence counter so that ids stay unique within the session.
+func (s *Splitter) resetSession(st *SplitterStatus) {
+	st.BetweenMark = false
+	st.NoSplitCount = 0
+	st.MarkType.Init()
+	st.MarkForm.Init()
+	st.buffer = NewSentence()
+}
+
 func (s *Splitter) Split(st *SplitterStatus, words []*Word, flush bool) []*Sentence {
 	var sentences []*Sentence
 
@@ -169,10 +188,7 @@ func (s *Splitter) Split(st *SplitterStatus, words []*Word, flush bool) []*Sente
 					st.nsentence++
 					st.buffer.sentID = strconv.Itoa(st.nsentence)
 					sentences = append(sentences, st.buffer)
-					nsentence := st.nsentence
-					s.CloseSession(st)
-					st = s.OpenSession()
-					st.nsentence = nsentence
+					s.resetSession(st)
 				} else {
 					st.buffer.PushBack(w)
 				}
@@ -186,10 +202,7 @@ func (s *Splitter) Split(st *SplitterStatus, words []*Word, flush bool) []*Sente
 		st.nsentence++
 		st.buffer.sentID = strconv.Itoa(st.nsentence)
 		sentences = append(sentences, st.buffer)
-		nsentence := st.nsentence
-		s.CloseSession(st)
-		st = s.OpenSession()
-		st.nsentence = nsentence
+		s.resetSession(st)
 	}
 
 	return sentences
@@ -202,6 +215,6 @@ func (s *Splitter) endOfSentence(i int, words []*Word) bool {
 		r := words[i+1]
 		f := r.getForm()
 
-		return strings.Title(f) == f || s.starters.Has(f)
+		return IsCapitalized(f) || s.starters.Has(f)
 	}
 }
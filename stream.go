@@ -0,0 +1,169 @@
+package linguo
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ruggi/linguo/models"
+)
+
+const streamChunkSize = 64 * 1024
+
+// streamMaxBuffer is the most text without whitespace a SentenceScanner keeps
+// waiting for the rest of a token.
+const streamMaxBuffer = 1024 * 1024
+
+// SentenceScanner reads a document from an io.Reader and analyses it one
+// sentence at a time, keeping the splitter session open across reads so that
+// only the text of the sentence being built is held in memory.
+//
+// Every stage runs as each sentence is completed, so document-level stages
+// such as UKB see a single sentence at a time. Document-level entity extraction
+// is not performed.
+//
+// The scanner stops with ErrClosed once the engine is closed, which
+// Engine.Reload does with the engine it replaces.
+type SentenceScanner struct {
+	engine  *NLPEngine
+	ctx     context.Context
+	r       io.Reader
+	status  *SplitterStatus
+	chunk   []byte
+	buf     []byte
+	offset  int
+	runes   int
+	pending []*Sentence
+	current *models.SentenceEntity
+	done    bool
+	err     error
+}
+
+func (e *NLPEngine) NewSentenceScanner(ctx context.Context, r io.Reader) *SentenceScanner {
+	e.closeLock.RLock()
+	defer e.closeLock.RUnlock()
+	if e.closed {
+		return &SentenceScanner{engine: e, err: ErrClosed}
+	}
+	return &SentenceScanner{
+		engine: e,
+		ctx:    ctx,
+		r:      r,
+		status: e.splitter.OpenSession(),
+		chunk:  make([]byte, streamChunkSize),
+	}
+}
+
+// Scan advances to the next analysed sentence, which is then available via
+// Sentence. It returns false at the end of the input or on error.
+//
+// The engine is not held while reading, so a slow or idle reader does not
+// stop Close or Reload; the scanner then fails with ErrClosed on its next
+// use of the engine.
+func (s *SentenceScanner) Scan() bool {
+	for len(s.pending) == 0 {
+		if s.done || s.err != nil {
+			return false
+		}
+		eof := s.read()
+		if s.err != nil || !s.lock() {
+			return false
+		}
+		s.split(eof)
+		s.engine.closeLock.RUnlock()
+	}
+
+	if !s.lock() {
+		return false
+	}
+	defer s.engine.closeLock.RUnlock()
+
+	sentence := s.pending[0]
+	s.pending[0] = nil
+	s.pending = s.pending[1:]
+
+	if err := s.engine.analyzeSentence(s.ctx, sentence); err != nil {
+		s.err = err
+		return false
+	}
+
+	entities := s.engine.sentenceEntities([]*Sentence{sentence})
+	s.current = entities[0]
+	return true
+}
+
+// lock holds the engine for reading, unless it is closed, in which case the
+// scanner fails with ErrClosed.
+func (s *SentenceScanner) lock() bool {
+	s.engine.closeLock.RLock()
+	if s.engine.closed {
+		s.engine.closeLock.RUnlock()
+		s.err = ErrClosed
+		return false
+	}
+	return true
+}
+
+// Sentence returns the sentence produced by the last successful call to Scan.
+func (s *SentenceScanner) Sentence() *models.SentenceEntity {
+	return s.current
+}
+
+// Err returns the first error found while reading or analysing the input.
+func (s *SentenceScanner) Err() error {
+	return s.err
+}
+
+// read appends the next chunk of the input to buf and tells whether the end
+// of the input was reached.
+func (s *SentenceScanner) read() bool {
+	if err := s.ctx.Err(); err != nil {
+		s.err = err
+		return false
+	}
+
+	n, err := s.r.Read(s.chunk)
+	s.buf = append(s.buf, s.chunk[:n]...)
+	if err != nil && err != io.EOF {
+		s.err = err
+	}
+	return err == io.EOF
+}
+
+// split splits the complete tokens read so far into sentences. Text after the
+// last whitespace is kept for the next read since the tokenizer never matches
+// across whitespace, unless there is more than streamMaxBuffer of it: the
+// text is then cut after its last whole character.
+func (s *SentenceScanner) split(eof bool) {
+	cut := len(s.buf)
+	if !eof {
+		if i := bytes.LastIndexFunc(s.buf, unicode.IsSpace); i >= 0 {
+			_, size := utf8.DecodeRune(s.buf[i:])
+			cut = i + size
+		} else if len(s.buf) < streamMaxBuffer {
+			return
+		} else {
+			for k := 1; k <= utf8.UTFMax && k <= cut; k++ {
+				if utf8.RuneStart(s.buf[cut-k]) {
+					if !utf8.FullRune(s.buf[cut-k:]) {
+						cut -= k
+					}
+					break
+				}
+			}
+		}
+	}
+
+	words := s.engine.tokenizer.TokenizeAt(string(s.buf[:cut]), s.offset, s.runes)
+	s.offset += cut
+	s.runes += utf8.RuneCount(s.buf[:cut])
+	s.buf = append(s.buf[:0], s.buf[cut:]...)
+
+	s.pending = s.engine.splitter.Split(s.status, words, eof)
+	if eof {
+		s.engine.splitter.CloseSession(s.status)
+		s.done = true
+	}
+}
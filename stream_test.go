@@ -0,0 +1,136 @@
+package linguo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ruggi/linguo/models"
+)
+
+// chunkReader returns the text at most n bytes at a time.
+type chunkReader struct {
+	text string
+	n    int
+}
+
+func (this *chunkReader) Read(p []byte) (int, error) {
+	if this.text == "" {
+		return 0, io.EOF
+	}
+	n := copy(p[:min(len(p), this.n)], this.text)
+	this.text = this.text[n:]
+	return n, nil
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func scanAll(t *testing.T, s *SentenceScanner) []*models.SentenceEntity {
+	t.Helper()
+	var sentences []*models.SentenceEntity
+	for s.Scan() {
+		sentences = append(sentences, s.Sentence())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return sentences
+}
+
+// sentenceSpans writes the sentences with the spans of their tokens.
+func sentenceSpans(sentences []*models.SentenceEntity) string {
+	var out []string
+	for _, s := range sentences {
+		out = append(out, fmt.Sprintf("[%d:%d %d:%d]", s.Start, s.End, s.RuneStart, s.RuneEnd))
+		for _, t := range s.Tokens {
+			out = append(out, fmt.Sprintf("%s/%s/%s@%d:%d,%d:%d", t.Base, t.Lemma, t.Pos, t.Start, t.End, t.RuneStart, t.RuneEnd))
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// TestSentenceScanner reads the text in chunks of several sizes and checks
+// the sentences and their offsets against Workflow.
+func TestSentenceScanner(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	input := "  " + strings.Join(offsetInputs, "\n") + "\n" + strings.Join(concurrentInputs, " ")
+	want := sentenceSpans(e.Workflow(input).Sentences)
+
+	for _, n := range []int{1, 3, 16, streamChunkSize} {
+		s := e.NewSentenceScanner(context.Background(), &chunkReader{input, n})
+		if got := sentenceSpans(scanAll(t, s)); got != want {
+			t.Errorf("chunks of %d bytes:\ngot  %s\nwant %s", n, got, want)
+		}
+	}
+}
+
+// TestSentenceScannerLongToken reads a token longer than streamMaxBuffer,
+// which is cut instead of being kept whole in memory.
+func TestSentenceScannerLongToken(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	long := strings.Repeat("é", streamMaxBuffer)
+	input := "The robot " + long + " was created."
+
+	var forms []string
+	for _, sentence := range scanAll(t, e.NewSentenceScanner(context.Background(), strings.NewReader(input))) {
+		for _, tok := range sentence.Tokens {
+			if len(tok.Base) > streamMaxBuffer+streamChunkSize {
+				t.Errorf("token of %d bytes", len(tok.Base))
+			}
+			if input[tok.Start:tok.End] != tok.Base || string([]rune(input)[tok.RuneStart:tok.RuneEnd]) != tok.Base {
+				t.Errorf("token of %d bytes at [%d:%d] does not match the input", len(tok.Base), tok.Start, tok.End)
+			}
+			forms = append(forms, tok.Base)
+		}
+	}
+	if got, want := strings.Join(forms, ""), strings.Replace(input, " ", "", -1); got != want {
+		t.Errorf("the tokens do not cover the input")
+	}
+}
+
+// blockedReader blocks in Read until release is closed.
+type blockedReader struct {
+	release chan struct{}
+}
+
+func (this *blockedReader) Read(p []byte) (int, error) {
+	<-this.release
+	return copy(p, "The robot was created."), io.EOF
+}
+
+// TestSentenceScannerBlockedRead closes the engine while a scanner waits for
+// its reader.
+func TestSentenceScannerBlockedRead(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	r := &blockedReader{release: make(chan struct{})}
+	s := e.NewSentenceScanner(context.Background(), r)
+
+	scanned := make(chan bool)
+	go func() { scanned <- s.Scan() }()
+	time.Sleep(10 * time.Millisecond)
+
+	closed := make(chan error)
+	go func() { closed <- e.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close waits for the reader of a scanner")
+	}
+
+	close(r.release)
+	if <-scanned || !errors.Is(s.Err(), ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", s.Err())
+	}
+}
@@ -21,13 +21,12 @@ type Affixes struct {
 	Longest        [2]int
 }
 
-func NewAffixes(sufFile string) *Affixes {
+func NewAffixes(sufFile string) (*Affixes, error) {
 	this := Affixes{}
 
 	filestr, err := ioutil.ReadFile(sufFile)
 	if err != nil {
-		CRASH("Error opening file "+sufFile, MOD_AFFIX)
-		return nil
+		return nil, openError(MOD_AFFIX, sufFile, err)
 	}
 	lines := strings.Split(string(filestr), "\n")
 
@@ -35,7 +34,7 @@ func NewAffixes(sufFile string) *Affixes {
 	this.Longest[PREF] = 0
 
 	kind := -1
-	for _, line := range lines {
+	for n, line := range lines {
 		if line != "" && !strings.HasPrefix(line, "#") {
 			items := Split(line, "\t")
 			if line == "<Suffixes>" {
@@ -47,6 +46,11 @@ func NewAffixes(sufFile string) *Affixes {
 			} else if line == "</Prefixes>" {
 				kind = -1
 			} else if kind == SUF || kind == PREF {
+				if len(items) < 10 {
+					err := newConfigError(MOD_AFFIX, sufFile, n+1, "expected 10 fields, found %d", len(items))
+					err.Section = If(kind == SUF, "Suffixes", "Prefixes").(string)
+					return nil, err
+				}
 				key := items[0]
 				term := items[1]
 				cond := items[2]
@@ -58,7 +62,12 @@ func NewAffixes(sufFile string) *Affixes {
 				always := items[8]
 				retok := items[9]
 
-				suf := NewSufRuleFromRexEx(cond)
+				suf, err := NewSufRuleFromRexEx(cond)
+				if err != nil {
+					err := newConfigError(MOD_AFFIX, sufFile, n+1, "invalid condition '%s': %s", cond, err.Error())
+					err.Section = If(kind == SUF, "Suffixes", "Prefixes").(string)
+					return nil, err
+				}
 				suf.term = term
 				suf.output = output
 				suf.acc, _ = strconv.Atoi(acc)
@@ -107,7 +116,7 @@ func NewAffixes(sufFile string) *Affixes {
 
 	TRACE(3, "analyzer succesfully created", MOD_AFFIX)
 
-	return &this
+	return &this, nil
 }
 
 func (this *Affixes) lookFowAffixes(w *Word, dic *Dictionary) {
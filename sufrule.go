@@ -12,11 +12,15 @@ func NewEmptySufRule() *sufrule {
 	return &sufrule{}
 }
 
-func NewSufRuleFromRexEx(c string) *sufrule {
+func NewSufRuleFromRexEx(c string) (*sufrule, error) {
+	cond, err := regexp.Compile(c)
+	if err != nil {
+		return nil, err
+	}
 	return &sufrule{
 		expression: c,
-		cond:       regexp.MustCompile(c),
-	}
+		cond:       cond,
+	}, nil
 }
 
 func NewSufRuleFromSufRule(c *sufrule) *sufrule {
@@ -22,7 +22,7 @@ const (
 	DECOMPOSITION_RULES
 )
 
-func NewTagset(ftagset string) *TagSet {
+func NewTagset(ftagset string) (*TagSet, error) {
 	this := &TagSet{
 		PAIR_SEP:  "=",
 		MSD_SEP:   "|",
@@ -38,9 +38,10 @@ func NewTagset(ftagset string) *TagSet {
 	cfg := NewConfigFile(false, "##")
 	cfg.AddSection("DirectTranslations", DIRECT_TRANSLATIONS)
 	cfg.AddSection("DecompositionRules", DECOMPOSITION_RULES)
+	cfg.module = MOD_TAG_SET
 
-	if !cfg.Open(ftagset) {
-		CRASH("Error opening file "+ftagset, MOD_TAG_SET)
+	if err := cfg.Open(ftagset); err != nil {
+		return nil, err
 	}
 
 	line := ""
@@ -49,6 +50,9 @@ func NewTagset(ftagset string) *TagSet {
 		switch cfg.section {
 		case DIRECT_TRANSLATIONS:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				tag := items[0]
 				shtag := items[1]
 				msd := ""
@@ -61,6 +65,9 @@ func NewTagset(ftagset string) *TagSet {
 			}
 		case DECOMPOSITION_RULES:
 			{
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return nil, err
+				}
 				cat := items[0]
 				shsz := items[1]
 				if len(items) > 2 {
@@ -80,11 +87,17 @@ func NewTagset(ftagset string) *TagSet {
 					msd := items[4]
 					key := cat + "#" + strconv.Itoa(i)
 					k := strings.Split(msd, "/")
+					if len(k) < 2 {
+						return nil, cfg.Errorf("invalid feature definition '%s'", msd)
+					}
 					this.feat[key] = k[0]
 					this.feat[cat+"#"+k[0]] = strconv.Itoa(i)
 					v := strings.Split(k[1], ";")
 					for j := 0; j < len(v); j++ {
 						t := strings.Split(v[j], ":")
+						if len(t) < 2 {
+							return nil, cfg.Errorf("invalid feature value '%s'", v[j])
+						}
 						this.val[key+"#"+strings.ToUpper(t[0])] = t[1]
 						this.valInv[key+"#"+t[1]] = strings.ToUpper(t[0])
 					}
@@ -100,7 +113,7 @@ func NewTagset(ftagset string) *TagSet {
 
 	TRACE(1, "Module created successfully", MOD_HMM)
 
-	return this
+	return this, nil
 }
 
 func (this TagSet) GetShortTag(tag string) string {
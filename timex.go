@@ -0,0 +1,186 @@
+package linguo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ruggi/linguo/models"
+)
+
+// STAGE_TIMEX is the stage that resolves the dates found by Maco, run right
+// after it when dates detection is on.
+const STAGE_TIMEX = "timex"
+
+var timexWeekdays = map[string]time.Weekday{
+	"L": time.Monday, "M": time.Tuesday, "X": time.Wednesday, "J": time.Thursday,
+	"V": time.Friday, "S": time.Saturday, "G": time.Sunday,
+}
+
+// timexDayparts gives the hours a part of the day starts and ends at, the
+// night ending the next morning.
+var timexDayparts = map[string][2]int{
+	"MO": {6, 12}, "AF": {12, 18}, "EV": {18, 22}, "NI": {22, 30},
+}
+
+// TimexResolver resolves the date and time expressions recognized by the dates
+// module of Maco ("next Tuesday", "two weeks ago", "yesterday evening") into
+// ISO 8601 values against a reference time, the one given in
+// WorkflowOptions.ReferenceTime or else the time of the call. The result is
+// set in the Timex of the tokens.
+type TimexResolver struct {
+}
+
+func NewTimexResolver() *TimexResolver {
+	return &TimexResolver{}
+}
+
+func (this *TimexResolver) Analyze(s *Sentence) {
+	this.resolve(s, time.Now())
+}
+
+func (this *TimexResolver) analyzeWithOptions(ctx context.Context, s *Sentence, opts *WorkflowOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	ref := opts.ReferenceTime
+	if ref.IsZero() {
+		ref = time.Now()
+	}
+	this.resolve(s, ref)
+	return nil
+}
+
+func (this *TimexResolver) resolve(s *Sentence, ref time.Time) {
+	for i := s.Front(); i != nil; i = i.Next() {
+		w := i.Value.(*Word)
+		if w.date == nil {
+			continue
+		}
+		w.timex = w.date.resolve(ref)
+		if w.timex != nil {
+			TRACE(3, fmt.Sprintf("Resolved %s to %s", w.getForm(), w.timex.Value), MOD_DATES)
+		}
+	}
+}
+
+// period is the ISO 8601 duration of count units.
+func (this dateValue) period() string {
+	switch this.unit {
+	case "H":
+		return fmt.Sprintf("PT%dH", this.count)
+	case "MI":
+		return fmt.Sprintf("PT%dM", this.count)
+	case "WE":
+		return fmt.Sprintf("P%dWE", this.count)
+	}
+	return fmt.Sprintf("P%d%s", this.count, this.unit)
+}
+
+// resolve turns the value into a Timex taking ref as the time the expression
+// is said in. Missing parts of absolute dates are taken from ref, so "March
+// 3rd" is March 3rd of the year of ref. It returns nil when that gives a day
+// the month does not have, as "February 29th" in 2017.
+func (this dateValue) resolve(ref time.Time) *models.Timex {
+	if this.duration {
+		return models.NewTimex(models.TIMEX_DURATION, this.period())
+	}
+
+	loc := ref.Location()
+	day := time.Date(ref.Year(), ref.Month(), ref.Day(), 0, 0, 0, 0, loc)
+	grain := "D"
+	switch {
+	case this.unit == "H" || this.unit == "MI":
+		d := time.Duration(this.shift) * If(this.unit == "H", time.Hour, time.Minute).(time.Duration)
+		t := ref.Add(d).Truncate(time.Minute)
+		timex := models.NewTimex(models.TIMEX_TIME, t.Format("2006-01-02T15:04"))
+		timex.SetInterval(t, t.Add(time.Minute))
+		return timex
+	case this.unit == "D":
+		day = day.AddDate(0, 0, this.shift)
+	case this.unit == "W", this.unit == "WE":
+		day = day.AddDate(0, 0, 7*this.shift)
+		if this.count < 0 || this.unit == "WE" {
+			grain = this.unit
+		}
+	case this.unit == "M":
+		day = time.Date(ref.Year(), ref.Month()+time.Month(this.shift), 1, 0, 0, 0, 0, loc)
+		grain = "M"
+	case this.unit == "Y":
+		day = time.Date(ref.Year()+this.shift, 1, 1, 0, 0, 0, 0, loc)
+		grain = "Y"
+	case this.weekday != "" && this.day < 0 && this.month < 0:
+		// the next such weekday, or the last one before ref
+		diff := int(timexWeekdays[this.weekday]-day.Weekday()+7) % 7
+		if this.hasDir && this.dir < 0 {
+			diff -= 7
+		} else if this.hasDir && this.dir > 0 && diff == 0 {
+			diff = 7
+		}
+		day = day.AddDate(0, 0, diff)
+	case this.day >= 0 || this.month >= 0 || this.year >= 0:
+		year, month := ref.Year(), ref.Month()
+		if this.year >= 0 {
+			year = this.year
+			if year < 100 {
+				year += 2000
+			}
+		}
+		if this.month >= 0 {
+			month = time.Month(this.month)
+		}
+		switch {
+		case this.day >= 0:
+			day = time.Date(year, month, this.day, 0, 0, 0, 0, loc)
+			if day.Day() != this.day || day.Month() != month {
+				return nil
+			}
+		case this.month >= 0:
+			day, grain = time.Date(year, month, 1, 0, 0, 0, 0, loc), "M"
+		default:
+			day, grain = time.Date(year, 1, 1, 0, 0, 0, 0, loc), "Y"
+		}
+	}
+
+	if this.hour >= 0 && grain == "D" {
+		hour := this.hour
+		if this.ampm == "pm" && hour < 12 {
+			hour += 12
+		} else if this.ampm == "am" && hour == 12 {
+			hour = 0
+		}
+		t := day.Add(time.Duration(hour)*time.Hour + time.Duration(this.minute)*time.Minute)
+		timex := models.NewTimex(models.TIMEX_TIME, t.Format("2006-01-02T15:04"))
+		timex.SetInterval(t, t.Add(time.Minute))
+		return timex
+	}
+	if hours, ok := timexDayparts[this.daypart]; ok && grain == "D" {
+		timex := models.NewTimex(models.TIMEX_TIME, day.Format("2006-01-02")+"T"+this.daypart)
+		timex.SetInterval(day.Add(time.Duration(hours[0])*time.Hour), day.Add(time.Duration(hours[1])*time.Hour))
+		return timex
+	}
+
+	var timex *models.Timex
+	switch grain {
+	case "D":
+		timex = models.NewTimex(models.TIMEX_DATE, day.Format("2006-01-02"))
+		timex.SetInterval(day, day.AddDate(0, 0, 1))
+	case "W", "WE":
+		monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		year, week := monday.ISOWeek()
+		if grain == "W" {
+			timex = models.NewTimex(models.TIMEX_DATE, fmt.Sprintf("%04d-W%02d", year, week))
+			timex.SetInterval(monday, monday.AddDate(0, 0, 7))
+		} else {
+			timex = models.NewTimex(models.TIMEX_DATE, fmt.Sprintf("%04d-W%02d-WE", year, week))
+			timex.SetInterval(monday.AddDate(0, 0, 5), monday.AddDate(0, 0, 7))
+		}
+	case "M":
+		timex = models.NewTimex(models.TIMEX_DATE, day.Format("2006-01"))
+		timex.SetInterval(day, day.AddDate(0, 1, 0))
+	case "Y":
+		timex = models.NewTimex(models.TIMEX_DATE, day.Format("2006"))
+		timex.SetInterval(day, day.AddDate(1, 0, 0))
+	}
+	return timex
+}
@@ -0,0 +1,65 @@
+package linguo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimexResolve(t *testing.T) {
+	// a Wednesday, in ISO week 9
+	ref := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		lang, text, want string
+	}{
+		{"en", "next Tuesday", "2017-03-07"},
+		{"en", "last Tuesday", "2017-02-28"},
+		{"en", "two weeks ago", "2017-02-15"},
+		{"en", "next week", "2017-W10"},
+		{"en", "next month", "2017-04"},
+		{"en", "yesterday evening", "2017-02-28TEV"},
+		{"en", "tomorrow at half past five pm", "2017-03-02T17:30"},
+		{"en", "March 3rd , 2017", "2017-03-03"},
+		{"en", "March 3rd", "2017-03-03"},
+		{"en", "for three days", "P3D"},
+		{"en", "February 29", ""},
+		{"en", "February 29 , 2016", "2016-02-29"},
+		{"es", "el próximo martes", "2017-03-07"},
+		{"es", "hace dos semanas", "2017-02-15"},
+		{"es", "ayer por la tarde", "2017-02-28TAF"},
+		{"es", "el 30 de abril", "2017-04-30"},
+	} {
+		s := newDatesSentence(t, tt.lang, tt.text)
+		NewTimexResolver().resolve(s, ref)
+		var got []string
+		for _, w := range s.Words() {
+			if w.date == nil {
+				continue
+			}
+			if w.timex == nil {
+				got = append(got, "")
+			} else {
+				got = append(got, w.timex.Value)
+			}
+		}
+		if len(got) != 1 {
+			t.Errorf("%s: %q: got %d dates, want 1", tt.lang, tt.text, len(got))
+		} else if got[0] != tt.want {
+			t.Errorf("%s: %q: got %q, want %q", tt.lang, tt.text, got[0], tt.want)
+		}
+	}
+}
+
+// TestTimexImpossibleDates resolves dates whose day is not in their month,
+// which the dates module does not give.
+func TestTimexImpossibleDates(t *testing.T) {
+	ref := time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, v := range []dateValue{
+		{day: 31, month: 2, year: 2017, hour: -1, minute: -1, count: -1},
+		{day: 29, month: 2, year: -1, hour: -1, minute: -1, count: -1},
+		{day: 31, month: 4, year: -1, hour: 5, minute: 30, count: -1},
+	} {
+		if timex := v.resolve(ref); timex != nil {
+			t.Errorf("%s: got %s, want nil", v.lemma(), timex.Value)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package linguo
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenSpan is the position of a token in a text, in bytes, End excluded, as
+// given by strings.Index and slicing. Rune offsets, as counted by other tools,
+// must be converted first.
+type TokenSpan struct {
+	Start int
+	End   int
+}
+
+// WorkflowTokens analyses text that is already split into sentences and
+// tokens, such as a CoNLL corpus, without running the tokenizer and the
+// splitter: every token becomes a word as given. The offsets in the Result
+// refer to the text made by joining all the tokens with single spaces, which
+// is also the text given to the entity extractor.
+func (e *NLPEngine) WorkflowTokens(ctx context.Context, sentences [][]string, opts *WorkflowOptions) (Result, error) {
+	var text strings.Builder
+	offsets := make([][]TokenSpan, len(sentences))
+	for n, tokens := range sentences {
+		offsets[n] = make([]TokenSpan, len(tokens))
+		for i, t := range tokens {
+			if text.Len() > 0 {
+				text.WriteByte(' ')
+			}
+			offsets[n][i] = TokenSpan{Start: text.Len(), End: text.Len() + len(t)}
+			text.WriteString(t)
+		}
+	}
+	return e.WorkflowTokensAt(ctx, text.String(), sentences, offsets, opts)
+}
+
+// WorkflowTokensAt is WorkflowTokens for tokens taken from text, offsets
+// holding the byte span of every token. The offsets in the Result then refer
+// to text, and the entity extractor runs on it. A span outside text, or one
+// that cuts a multibyte character, is an error.
+func (e *NLPEngine) WorkflowTokensAt(ctx context.Context, text string, sentences [][]string, offsets [][]TokenSpan, opts *WorkflowOptions) (Result, error) {
+	if !e.acquire() {
+		return Result{}, ErrClosed
+	}
+	defer e.release()
+
+	if opts == nil {
+		opts = NewWorkflowOptions()
+	}
+	if err := ctx.Err(); err != nil {
+		return Result{}, err
+	}
+	stages, err := e.selectStages(opts.Stages)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(offsets) != len(sentences) {
+		return Result{}, fmt.Errorf("%d offset lists for %d sentences", len(offsets), len(sentences))
+	}
+
+	runes := newRuneCounter(text)
+	input := make([]*Sentence, 0, len(sentences))
+	for n, tokens := range sentences {
+		if len(offsets[n]) != len(tokens) {
+			return Result{}, fmt.Errorf("sentence %d: %d offsets for %d tokens", n, len(offsets[n]), len(tokens))
+		}
+		if len(tokens) == 0 {
+			continue
+		}
+
+		s := NewSentence()
+		for i, t := range tokens {
+			span := offsets[n][i]
+			if t == "" {
+				return Result{}, fmt.Errorf("sentence %d: empty token %d", n, i)
+			}
+			if span.Start < 0 || span.End < span.Start || span.End > len(text) || !runeBoundary(text, span.Start) || !runeBoundary(text, span.End) {
+				return Result{}, fmt.Errorf("sentence %d: token %q has invalid offsets [%d,%d)", n, t, span.Start, span.End)
+			}
+			w := NewWordFromLemma(t)
+			w.setSpan(span.Start, span.End)
+			w.setRuneSpan(runes.at(span.Start), runes.at(span.End))
+			s.PushBack(w)
+		}
+		s.setSentenceID(strconv.Itoa(len(input) + 1))
+		input = append(input, s)
+	}
+
+	return e.analyze(ctx, text, input, stages, opts)
+}
+
+// runeBoundary tells whether offset is the start of a rune of text, or its
+// end.
+func runeBoundary(text string, offset int) bool {
+	return offset == len(text) || utf8.RuneStart(text[offset])
+}
+
+// runeCounter converts byte offsets of a text into rune offsets. It is
+// fastest when the offsets are asked for in increasing order.
+type runeCounter struct {
+	text  string
+	bytes int
+	runes int
+}
+
+func newRuneCounter(text string) *runeCounter {
+	return &runeCounter{text: text}
+}
+
+func (this *runeCounter) at(offset int) int {
+	if offset < this.bytes {
+		this.bytes, this.runes = 0, 0
+	}
+	this.runes += utf8.RuneCountInString(this.text[this.bytes:offset])
+	this.bytes = offset
+	return this.runes
+}
@@ -0,0 +1,139 @@
+package linguo
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// tokenSpans returns the tokens of every sentence together with their spans
+// in text, found by looking for each token after the previous one.
+func tokenSpans(t *testing.T, text string, sentences [][]string) [][]TokenSpan {
+	t.Helper()
+	offsets := make([][]TokenSpan, len(sentences))
+	pos := 0
+	for n, tokens := range sentences {
+		for _, tok := range tokens {
+			i := strings.Index(text[pos:], tok)
+			if i < 0 {
+				t.Fatalf("token %q not in %q", tok, text[pos:])
+			}
+			offsets[n] = append(offsets[n], TokenSpan{Start: pos + i, End: pos + i + len(tok)})
+			pos += i + len(tok)
+		}
+	}
+	return offsets
+}
+
+// checkTokenOffsets checks the offsets of the tokens of r against text.
+func checkTokenOffsets(t *testing.T, text string, r Result) {
+	t.Helper()
+	for _, s := range r.Sentences {
+		checkRunes(t, text, "sentence "+s.Body, s.Start, s.End, s.RuneStart, s.RuneEnd)
+		for _, tok := range s.Tokens {
+			checkRunes(t, text, "token "+tok.Base, tok.Start, tok.End, tok.RuneStart, tok.RuneEnd)
+			if surface := text[tok.Start:tok.End]; !surfaceOf(tok.Base, surface) {
+				t.Errorf("token %q has surface %q", tok.Base, surface)
+			}
+		}
+	}
+}
+
+func TestWorkflowTokens(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	sentences := [][]string{
+		{"The", "robot", "was", "created", "."},
+		{},
+		{"It", "is", "from", "the", "episode", "."},
+	}
+	r, err := e.WorkflowTokens(context.Background(), sentences, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resultString(r), "The/the/DT robot/robot/NN was/be/VBD created/create/VBD ././Fp | It/it/PRP is/be/VBZ from/from/IN the/the/DT episode/episode/NN ././Fp |"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	checkTokenOffsets(t, "The robot was created . It is from the episode .", r)
+}
+
+// TestWorkflowTokensAt gives the tokens of a text with multibyte characters,
+// spaced as in the text.
+func TestWorkflowTokensAt(t *testing.T) {
+	o := newTestNLPOptions(newTestMacoOptions()).
+		WithEntityExtractor(valueExtractor{"Zoë Ångström"})
+	e := newTestEngineOptions(t, o)
+
+	text := "The robot  was created by Zoë Ångström.\n« It is from the episode. »"
+	sentences := [][]string{
+		{"The", "robot", "was", "created", "by", "Zoë", "Ångström", "."},
+		{"«", "It", "is", "from", "the", "episode", ".", "»"},
+	}
+	r, err := e.WorkflowTokensAt(context.Background(), text, sentences, tokenSpans(t, text, sentences), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Sentences) != 2 {
+		t.Fatalf("got %d sentences, want 2", len(r.Sentences))
+	}
+	checkTokenOffsets(t, text, r)
+	fromExtractor := 0
+	for _, m := range r.Entities {
+		checkRunes(t, text, "entity "+m.Value, m.Start, m.End, m.RuneStart, m.RuneEnd)
+		if !m.HasSource(ENTITY_SOURCE_EXTRACTOR) {
+			continue
+		}
+		fromExtractor++
+		if surface := text[m.Start:m.End]; surface != "Zoë Ångström" {
+			t.Errorf("entity %q has surface %q", m.Value, surface)
+		}
+	}
+	if fromExtractor == 0 {
+		t.Error("no entities from the extractor")
+	}
+}
+
+func TestWorkflowTokensAtErrors(t *testing.T) {
+	e := newTestEngine(t, newTestMacoOptions())
+	text := "The robot was créé."
+	sentences := [][]string{{"The", "robot", "was", "créé", "."}}
+	spans := func(last TokenSpan) [][]TokenSpan {
+		offsets := tokenSpans(t, text, sentences)
+		offsets[0][3] = last
+		return offsets
+	}
+
+	for _, tt := range []struct {
+		name      string
+		sentences [][]string
+		offsets   [][]TokenSpan
+	}{
+		{"fewer offset lists", [][]string{{"The"}, {"robot"}}, [][]TokenSpan{{{0, 3}}}},
+		{"fewer offsets", sentences, [][]TokenSpan{{{0, 3}, {4, 9}}}},
+		{"empty token", [][]string{{"The", ""}}, [][]TokenSpan{{{0, 3}, {3, 3}}}},
+		{"negative start", sentences, spans(TokenSpan{-1, 18})},
+		{"end before start", sentences, spans(TokenSpan{18, 14})},
+		{"end after the text", sentences, spans(TokenSpan{14, len(text) + 1})},
+		// créé is [14,20) in bytes, 17 is inside its first é
+		{"inside a rune", sentences, spans(TokenSpan{14, 17})},
+	} {
+		if _, err := e.WorkflowTokensAt(context.Background(), text, tt.sentences, tt.offsets, nil); err == nil {
+			t.Errorf("%s: got no error", tt.name)
+		}
+	}
+}
+
+func TestRuneCounter(t *testing.T) {
+	text := "aé€😀b"
+	runes := newRuneCounter(text)
+	for _, tt := range []struct {
+		offset, want int
+	}{
+		{0, 0}, {1, 1}, {3, 2}, {6, 3}, {10, 4}, {11, 5},
+		// going back starts over
+		{3, 2}, {0, 0}, {11, 5}, {6, 3},
+	} {
+		if got := runes.at(tt.offset); got != tt.want {
+			t.Errorf("at(%d): got %d, want %d", tt.offset, got, tt.want)
+		}
+	}
+}
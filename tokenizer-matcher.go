@@ -0,0 +1,316 @@
+package linguo
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	set "gopkg.in/fatih/set.v0"
+)
+
+// Maximum number of states of the tokenizer DFA. Past it, the transitions of
+// new states are computed as the text is read instead of being kept.
+const TOKENIZER_MAX_STATES = 4096
+
+// tokenizerRule is a rule of the RegExps section of the tokenizer file. re is
+// anchored at the start of the text and uses leftmost-longest matching.
+type tokenizerRule struct {
+	name    string
+	substr  int
+	abbrev  bool
+	pattern string
+	re      *regexp.Regexp
+	prog    *syntax.Prog
+}
+
+func newTokenizerRule(name string, substr int, pattern string) (tokenizerRule, error) {
+	re, err := regexp.Compile("^(?:" + pattern + ")")
+	if err != nil {
+		return tokenizerRule{}, err
+	}
+	re.Longest()
+
+	this := tokenizerRule{
+		name:    name,
+		substr:  substr,
+		abbrev:  strings.HasPrefix(name, "*"),
+		pattern: pattern,
+		re:      re,
+	}
+	// same flags as regexp.Compile
+	if parsed, err := syntax.Parse(pattern, syntax.Perl); err == nil {
+		if prog, err := syntax.Compile(parsed.Simplify()); err == nil && dfaCompatible(prog) {
+			this.prog = prog
+		}
+	}
+	return this, nil
+}
+
+// dfaCompatible tells whether the only assertions in prog are about the start
+// of the text, which always holds at the start of a token.
+func dfaCompatible(prog *syntax.Prog) bool {
+	for _, inst := range prog.Inst {
+		if inst.Op == syntax.InstEmptyWidth && syntax.EmptyOp(inst.Arg)&^(syntax.EmptyBeginText|syntax.EmptyBeginLine) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// tokens returns the tokens the rule makes of the start of s: the whole match
+// when substr is 0, otherwise its first substr groups. Groups are kept only
+// when they are a non empty prefix of the match, as RegExHasSuffix does.
+func (this *tokenizerRule) tokens(s string) []string {
+	m := this.re.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+	outs := make([]string, 0, len(m))
+	for _, out := range m {
+		if out != "" && strings.HasPrefix(m[0], out) {
+			outs = append(outs, strings.TrimSpace(out))
+		}
+	}
+	from := If(this.substr == 0, 0, 1).(int)
+	if len(outs) <= this.substr {
+		return nil
+	}
+	return outs[from : this.substr+1]
+}
+
+// tokenizerMatcher finds the rule that applies at the start of a text. The
+// rules are run together as a DFA that reads the text once and tells where
+// each of them matches, so the regexps are only needed to extract the groups
+// of the rules that split a match in several tokens. Rules using assertions
+// the DFA cannot evaluate, such as \b, are run through their regexp instead.
+//
+// The DFA is built for ASCII input when the matcher is created and is never
+// modified afterwards, so a matcher can be shared between goroutines.
+type tokenizerMatcher struct {
+	rules  []tokenizerRule
+	start  *tokenizerState
+	states map[string]*tokenizerState
+	list   []*tokenizerState
+}
+
+// tokenizerState is a set of states of the rule programs, each one encoded
+// as rule<<32|pc, and the rules that have matched once it is reached. next
+// holds its transitions on ASCII characters, as indexes in the state list.
+type tokenizerState struct {
+	id      int
+	insts   []uint64
+	accepts []int
+	next    []int32
+}
+
+const (
+	tokenizerDead    = -1
+	tokenizerUnknown = -2
+)
+
+func newTokenizerMatcher(rules []tokenizerRule) *tokenizerMatcher {
+	this := tokenizerMatcher{
+		rules:  rules,
+		states: make(map[string]*tokenizerState),
+	}
+
+	var insts []uint64
+	for n, r := range rules {
+		if r.prog != nil {
+			insts = this.closure(insts, n, uint32(r.prog.Start), true)
+		}
+	}
+	this.start = this.state(insts)
+
+	for i := 0; i < len(this.list); i++ {
+		s := this.list[i]
+		s.next = make([]int32, utf8.RuneSelf)
+		for c := range s.next {
+			insts := this.step(s, rune(c))
+			if len(insts) == 0 {
+				s.next[c] = tokenizerDead
+			} else if n := this.state(insts); n.id >= 0 {
+				s.next[c] = int32(n.id)
+			} else {
+				s.next[c] = tokenizerUnknown
+			}
+		}
+	}
+	return &this
+}
+
+func (this *tokenizerMatcher) closure(insts []uint64, rule int, pc uint32, atStart bool) []uint64 {
+	key := uint64(rule)<<32 | uint64(pc)
+	for _, i := range insts {
+		if i == key {
+			return insts
+		}
+	}
+
+	inst := &this.rules[rule].prog.Inst[pc]
+	switch inst.Op {
+	case syntax.InstAlt, syntax.InstAltMatch:
+		insts = this.closure(insts, rule, inst.Out, atStart)
+		insts = this.closure(insts, rule, inst.Arg, atStart)
+	case syntax.InstCapture, syntax.InstNop:
+		insts = this.closure(insts, rule, inst.Out, atStart)
+	case syntax.InstEmptyWidth:
+		if atStart {
+			insts = this.closure(insts, rule, inst.Out, atStart)
+		}
+	case syntax.InstFail:
+	default:
+		insts = append(insts, key)
+	}
+	return insts
+}
+
+// step returns the program states reached from s reading r.
+func (this *tokenizerMatcher) step(s *tokenizerState, r rune) []uint64 {
+	var insts []uint64
+	for _, i := range s.insts {
+		rule, pc := int(i>>32), uint32(i)
+		inst := &this.rules[rule].prog.Inst[pc]
+		if matchInst(inst, r) {
+			insts = this.closure(insts, rule, inst.Out, false)
+		}
+	}
+	return insts
+}
+
+func matchInst(inst *syntax.Inst, r rune) bool {
+	switch inst.Op {
+	case syntax.InstRune, syntax.InstRune1:
+		return inst.MatchRune(r)
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return r != '\n'
+	}
+	return false
+}
+
+// state returns the DFA state for a set of program states, adding it to the
+// DFA when it is new and there is still room for it; otherwise its id is -1.
+// It returns nil for the empty set. Only called while building the matcher.
+func (this *tokenizerMatcher) state(insts []uint64) *tokenizerState {
+	if len(insts) == 0 {
+		return nil
+	}
+	sort.Slice(insts, func(i, j int) bool { return insts[i] < insts[j] })
+	key := tokenizerKey(insts)
+	if s, ok := this.states[key]; ok {
+		return s
+	}
+
+	s := this.newState(insts)
+	if len(this.list) < TOKENIZER_MAX_STATES {
+		s.id = len(this.list)
+		this.states[key] = s
+		this.list = append(this.list, s)
+	}
+	return s
+}
+
+// lookup is state for use once the matcher is built: states not in the DFA
+// are returned without adding them.
+func (this *tokenizerMatcher) lookup(insts []uint64) *tokenizerState {
+	if len(insts) == 0 {
+		return nil
+	}
+	sort.Slice(insts, func(i, j int) bool { return insts[i] < insts[j] })
+	if s, ok := this.states[tokenizerKey(insts)]; ok {
+		return s
+	}
+	return this.newState(insts)
+}
+
+func (this *tokenizerMatcher) newState(insts []uint64) *tokenizerState {
+	s := &tokenizerState{id: -1, insts: insts}
+	for _, i := range insts {
+		rule, pc := int(i>>32), uint32(i)
+		if this.rules[rule].prog.Inst[pc].Op == syntax.InstMatch {
+			s.accepts = append(s.accepts, rule)
+		}
+	}
+	return s
+}
+
+// ends returns, for every rule the DFA runs, the end of its longest match at
+// the start of s, or -1 if it does not match.
+func (this *tokenizerMatcher) ends(s string) []int {
+	ends := make([]int, len(this.rules))
+	for n := range ends {
+		ends[n] = -1
+	}
+
+	cur := this.start
+	pos := 0
+	for cur != nil {
+		for _, rule := range cur.accepts {
+			ends[rule] = pos
+		}
+		if pos == len(s) {
+			break
+		}
+		r, size := rune(s[pos]), 1
+		if r >= utf8.RuneSelf {
+			r, size = utf8.DecodeRuneInString(s[pos:])
+		}
+		next := int32(tokenizerUnknown)
+		if r < utf8.RuneSelf && cur.next != nil {
+			next = cur.next[r]
+		}
+		switch next {
+		case tokenizerDead:
+			cur = nil
+		case tokenizerUnknown:
+			cur = this.lookup(this.step(cur, r))
+		default:
+			cur = this.list[next]
+		}
+		pos += size
+	}
+	return ends
+}
+
+// match returns the tokens made of the start of s by the first rule that
+// applies, or nil when none does. Rules whose name starts with '*' only apply
+// when every token is a known abbreviation.
+func (this *tokenizerMatcher) match(s string, abbrevs *set.Set) []string {
+	ends := this.ends(s)
+	for n := range this.rules {
+		rule := &this.rules[n]
+
+		var tokens []string
+		if rule.prog == nil || rule.substr > 0 {
+			if rule.prog != nil && ends[n] <= 0 {
+				continue
+			}
+			tokens = rule.tokens(s)
+		} else if ends[n] > 0 {
+			tokens = []string{strings.TrimSpace(s[:ends[n]])}
+		}
+
+		ok := len(strings.Join(tokens, "")) > 0
+		for _, t := range tokens {
+			if rule.abbrev && !abbrevs.Has(strings.ToLower(t)) {
+				ok = false
+			}
+		}
+		if ok {
+			return tokens
+		}
+	}
+	return nil
+}
+
+func tokenizerKey(insts []uint64) string {
+	b := make([]byte, 0, 8*len(insts))
+	for _, v := range insts {
+		b = append(b, byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32), byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+	return string(b)
+}
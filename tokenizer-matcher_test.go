@@ -0,0 +1,116 @@
+package linguo
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// perRuleMatch is the tokenizer before the rules were combined: every rule
+// is tried in turn through RegExHasSuffix until one matches.
+func perRuleMatch(tk *Tokenizer, s string) []string {
+	for _, rule := range tk.rules {
+		results := RegExHasSuffix(rule.re, s)
+		if len(results) <= rule.substr {
+			continue
+		}
+		tokens := results[If(rule.substr == 0, 0, 1).(int) : rule.substr+1]
+		match := true
+		for _, t := range tokens {
+			if rule.abbrev && !tk.abrevs.Has(strings.ToLower(t)) {
+				match = false
+			}
+		}
+		if match {
+			return tokens
+		}
+	}
+	return nil
+}
+
+// walkTokens splits p as TokenizeAt does, matching the rules with match.
+func walkTokens(p string, match func(string) []string) []string {
+	var out []string
+	cont := 0
+	for cont < len(p) {
+		r, size := utf8.DecodeRuneInString(p[cont:])
+		if unicode.IsSpace(r) {
+			cont += size
+			continue
+		}
+		delta := len(p)
+		if ps := strings.IndexFunc(p[cont:], unicode.IsSpace); ps > -1 {
+			delta = cont + ps
+		}
+		tokens := match(p[cont:delta])
+		if tokens == nil {
+			cont += size
+			continue
+		}
+		for _, t := range tokens {
+			out = append(out, t)
+			cont += len(t)
+		}
+	}
+	return out
+}
+
+func loadTokenizerCorpus(tb testing.TB) (*Tokenizer, string) {
+	tb.Helper()
+	tk, err := NewTokenizer("testdata/en/tokenizer.dat")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	corpus, err := ioutil.ReadFile("testdata/corpus.txt")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return tk, string(corpus)
+}
+
+// TestMatcherSameAsPerRule checks that the combined matcher makes the same
+// tokens as trying the rules one by one.
+func TestMatcherSameAsPerRule(t *testing.T) {
+	tk, corpus := loadTokenizerCorpus(t)
+	combined := func(s string) []string { return tk.matcher.match(s, tk.abrevs) }
+	perRule := func(s string) []string { return perRuleMatch(tk, s) }
+
+	for n, line := range strings.Split(corpus, "\n") {
+		got, want := walkTokens(line, combined), walkTokens(line, perRule)
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("line %d:\ngot  %q\nwant %q", n+1, got, want)
+		}
+	}
+
+	var forms []string
+	for _, w := range tk.Tokenize(corpus, 0) {
+		forms = append(forms, w.getForm())
+	}
+	if got, want := strings.Join(forms, " "), strings.Join(walkTokens(corpus, perRule), " "); got != want {
+		t.Errorf("Tokenize differs from the per rule tokens:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+// BenchmarkTokenize splits the corpus with the combined matcher and with the
+// rules tried one by one. Both go through walkTokens, so that only the
+// matching is measured.
+func BenchmarkTokenize(b *testing.B) {
+	tk, corpus := loadTokenizerCorpus(b)
+
+	b.Run("matcher", func(b *testing.B) {
+		combined := func(s string) []string { return tk.matcher.match(s, tk.abrevs) }
+		b.SetBytes(int64(len(corpus)))
+		for i := 0; i < b.N; i++ {
+			walkTokens(corpus, combined)
+		}
+	})
+	b.Run("per-rule", func(b *testing.B) {
+		perRule := func(s string) []string { return perRuleMatch(tk, s) }
+		b.SetBytes(int64(len(corpus)))
+		for i := 0; i < b.N; i++ {
+			walkTokens(corpus, perRule)
+		}
+	})
+}
@@ -2,9 +2,12 @@ package linguo
 
 import (
 	"container/list"
-	"regexp"
+	"fmt"
 	"strconv"
 	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
 
 	set "gopkg.in/fatih/set.v0"
 )
@@ -15,29 +18,70 @@ const (
 	TOKENIZER_ABBREV
 )
 
+const ZERO_WIDTH_JOINER = '\u200d'
+
+// posixClasses turns the POSIX character classes used by the tokenizer rules,
+// which are ASCII-only in Go, into their Unicode counterparts.
+var posixClasses = strings.NewReplacer(
+	"[:alpha:]", `\p{L}\p{M}`,
+	"[:alnum:]", `\p{L}\p{M}\p{N}`,
+	"[:upper:]", `\p{Lu}\p{Lt}`,
+	"[:lower:]", `\p{Ll}`,
+	"[:digit:]", `\p{Nd}`,
+	"[:punct:]", `\p{P}\p{S}`,
+	"[:space:]", `\s\p{Z}`,
+)
+
 type Tokenizer struct {
 	abrevs  *set.Set
-	rules   *list.List
-	matches map[string]int
+	macros  *list.List
+	lock    sync.RWMutex
+	rules   []tokenizerRule
+	matcher *tokenizerMatcher
 }
 
-func NewTokenizer(tokenizerFile string) *Tokenizer {
+// TokenizerRule is a rule as written in the RegExps section of a tokenizer
+// file. Pattern may use the macros of the files loaded so far, and rules
+// whose name starts with '*' only apply to known abbreviations.
+type TokenizerRule struct {
+	Name            string
+	Substr          int
+	Pattern         string
+	CaseInsensitive bool
+}
+
+func NewTokenizer(tokenizerFile string) (*Tokenizer, error) {
 	this := Tokenizer{
-		abrevs:  set.New(),
-		rules:   list.New(),
-		matches: make(map[string]int),
+		abrevs: set.New(),
+		macros: list.New(),
+	}
+	if err := this.Extend(tokenizerFile); err != nil {
+		return nil, err
 	}
+	return &this, nil
+}
 
+// Extend loads a supplementary tokenizer file. Its macros and abbreviations
+// are added to the ones already loaded, and its rules are tried before them.
+// Nothing is added if the file has an error, such as a rule that does not
+// compile.
+func (this *Tokenizer) Extend(tokenizerFile string) error {
 	cfg := NewConfigFile(false, "##")
 	cfg.AddSection("Macros", TOKENIZER_MACROS)
 	cfg.AddSection("RegExps", TOKENIZER_REGEXPS)
 	cfg.AddSection("Abbreviations", TOKENIZER_ABBREV)
+	cfg.module = MOD_TOKENIZER
 
-	if !cfg.Open(tokenizerFile) {
-		panic("Error opening file " + tokenizerFile)
+	if err := cfg.Open(tokenizerFile); err != nil {
+		return err
 	}
 
-	macros := list.New()
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	var macros []Pair
+	var rules []tokenizerRule
+	var abbrevs []string
 	rul := false
 	var ci string
 	line := ""
@@ -47,57 +91,43 @@ func NewTokenizer(tokenizerFile string) *Tokenizer {
 		case TOKENIZER_MACROS:
 			{
 				if rul {
-					panic("Error reading tokenizer configuration. Macros must be defined before rules.")
+					return cfg.Errorf("macros must be defined before rules")
+				}
+				if err := cfg.CheckFields(items, 2); err != nil {
+					return err
 				}
 				mname := items[0]
 				mvalue := items[1]
-				macros.PushBack(Pair{mname, mvalue})
+				macros = append(macros, Pair{mname, mvalue})
 				break
 			}
 		case TOKENIZER_REGEXPS:
 			{
-				var substr int
+				if err := cfg.CheckFields(items, 3); err != nil {
+					return err
+				}
 				comm := items[0]
-				substr, _ = strconv.Atoi(items[1])
-				re := items[2]
-				rul = true
-
-				for i := macros.Front(); i != nil; i = i.Next() {
-					mname := "{" + i.Value.(Pair).first.(string) + "}"
-					mvalue := i.Value.(Pair).second.(string)
-					p := strings.Index(re, mname)
-					for p > -1 {
-						re = strings.Replace(re, mname, mvalue, -1)
-						p = strings.Index(re[p:], mname)
-					}
+				substr, err := strconv.Atoi(items[1])
+				if err != nil {
+					return cfg.Errorf("invalid substring count '%s' for rule %s", items[1], comm)
 				}
+				rul = true
 
 				if len(items) > 3 {
 					ci = items[3]
 				}
 
-				if ci == "CI" {
-					newre := "(?i)" + re
-					x, err := regexp.Compile(newre)
-					if err == nil {
-						this.rules.PushBack(Pair{comm, x})
-					} else {
-					}
-				} else {
-					x, err := regexp.Compile(re)
-					if err == nil {
-						this.rules.PushBack(Pair{comm, x})
-					} else {
-					}
+				rule, err := this.newRule(TokenizerRule{comm, substr, items[2], ci == "CI"}, macros)
+				if err != nil {
+					return cfg.Errorf("invalid rule %s: %v", comm, err)
 				}
-
-				this.matches[comm] = substr
+				rules = append(rules, rule)
 				break
 
 			}
 		case TOKENIZER_ABBREV:
 			{
-				this.abrevs.Add(line)
+				abbrevs = append(abbrevs, line)
 				break
 			}
 		default:
@@ -105,74 +135,159 @@ func NewTokenizer(tokenizerFile string) *Tokenizer {
 		}
 	}
 
-	return &this
+	for i := len(macros) - 1; i >= 0; i-- {
+		this.macros.PushFront(macros[i])
+	}
+	this.addAbbreviations(abbrevs)
+	this.addRules(rules)
+	return nil
+}
+
+// AddAbbreviations adds words to the Abbreviations section, such as "approx."
+// or "fig.". Case is ignored.
+func (this *Tokenizer) AddAbbreviations(abbrevs ...string) {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+	this.addAbbreviations(abbrevs)
+}
+
+func (this *Tokenizer) addAbbreviations(abbrevs []string) {
+	for _, a := range abbrevs {
+		this.abrevs.Add(strings.ToLower(a))
+	}
+}
+
+// AddRules adds rules to the RegExps section, in the given order and before
+// the rules already loaded. Nothing is added if a rule is invalid.
+func (this *Tokenizer) AddRules(rules ...TokenizerRule) error {
+	this.lock.Lock()
+	defer this.lock.Unlock()
+
+	compiled := make([]tokenizerRule, 0, len(rules))
+	for _, r := range rules {
+		rule, err := this.newRule(r, nil)
+		if err != nil {
+			return fmt.Errorf("invalid tokenizer rule %s: %v", r.Name, err)
+		}
+		compiled = append(compiled, rule)
+	}
+	this.addRules(compiled)
+	return nil
+}
+
+func (this *Tokenizer) addRules(rules []tokenizerRule) {
+	if len(rules) == 0 && this.matcher != nil {
+		return
+	}
+	this.rules = append(rules, this.rules...)
+	this.matcher = newTokenizerMatcher(this.rules)
 }
 
+// newRule expands the macros in the pattern of r, those given first, and
+// compiles it.
+func (this *Tokenizer) newRule(r TokenizerRule, macros []Pair) (tokenizerRule, error) {
+	re := r.Pattern
+	expand := func(m Pair) {
+		mname := "{" + m.first.(string) + "}"
+		re = strings.Replace(re, mname, m.second.(string), -1)
+	}
+	for _, m := range macros {
+		expand(m)
+	}
+	for i := this.macros.Front(); i != nil; i = i.Next() {
+		expand(i.Value.(Pair))
+	}
+
+	re = posixClasses.Replace(re)
+
+	if r.CaseInsensitive {
+		re = "(?i)" + re
+	}
+	return newTokenizerRule(r.Name, r.Substr, re)
+}
+
+// Tokenize splits p into words whose spans start at offset. The rune spans
+// start at offset too, which is right as long as no multibyte character comes
+// before p; use TokenizeAt otherwise.
 func (this *Tokenizer) Tokenize(p string, offset int) []*Word {
-	var t [10]string
-	var i *list.Element
-	var match bool
-	substr := 0
-	ln := 0
+	return this.TokenizeAt(p, offset, offset)
+}
+
+// TokenizeAt splits p into words, p starting at the given byte and rune
+// offsets of the document.
+func (this *Tokenizer) TokenizeAt(p string, offset int, runeOffset int) []*Word {
+	this.lock.RLock()
+	matcher := this.matcher
+	this.lock.RUnlock()
 
 	var words []*Word
 
 	cont := 0
 	for cont < len(p) {
-		for WhiteSpace(p[cont]) {
-			cont++
-			offset++
-		}
-		match = false
-
-		for i = this.rules.Front(); i != nil && !match; i = i.Next() {
-			ps := strings.Index(p[cont:], " ")
-			delta := cont + ps
-			if ps == -1 {
-				delta = cont + len(p) - cont
-			}
-			results := RegExHasSuffix(i.Value.(Pair).second.(*regexp.Regexp), p[cont:delta])
-			if len(results) > 0 {
-				match = true
-				ln = 0
-				substr = this.matches[i.Value.(Pair).first.(string)]
-				for j := If(substr == 0, 0, 1).(int); j <= substr && match; j++ {
-					t[j] = results[j]
-					ln += len(t[j])
-					if string(i.Value.(Pair).first.(string)[0]) == "*" {
-						lower := strings.ToLower(t[j])
-						if !this.abrevs.Has(lower) {
-							match = false
-						}
-					}
-				}
+		for cont < len(p) {
+			r, size := utf8.DecodeRuneInString(p[cont:])
+			if !unicode.IsSpace(r) {
+				break
 			}
+			cont += size
+			offset += size
+			runeOffset++
+		}
+		if cont == len(p) {
+			break
+		}
 
+		// rules are matched against the text up to the next whitespace
+		delta := len(p)
+		if ps := strings.IndexFunc(p[cont:], unicode.IsSpace); ps > -1 {
+			delta = cont + ps
 		}
 
-		if match {
-			if i == nil {
-				i = this.rules.Back()
-			} else {
-				i = i.Prev()
-			}
-			substr = this.matches[i.Value.(Pair).first.(string)]
-			for j := If(substr == 0, 0, 1).(int); j <= substr && match; j++ {
-				if len(t[j]) > 0 {
-					w := NewWordFromLemma(t[j])
-					w.setSpan(offset, offset+len(t[j]))
-					offset += len(t[j])
-					words = append(words, w)
-				} else {
+		tokens := matcher.match(p[cont:delta], this.abrevs)
+		if tokens != nil {
+			for _, t := range tokens {
+				n := utf8.RuneCountInString(t)
+				form, start, runeStart := t, offset, runeOffset
+				if k := len(words) - 1; k >= 0 && words[k].getSpanFinish() == offset && continuesCluster(words[k].getForm(), t) {
+					// t goes on with the last character of the previous token
+					form = words[k].getForm() + t
+					start, runeStart = words[k].getSpanStart(), words[k].runeStart
+					words = words[:k]
 				}
+				offset += len(t)
+				runeOffset += n
+				cont += len(t)
+				w := NewWordFromLemma(form)
+				w.setSpan(start, offset)
+				w.setRuneSpan(runeStart, runeOffset)
+				words = append(words, w)
 			}
-			cont += ln
-		} else if cont < len(p) {
-			cont++
+		} else {
+			// no rule matches, skip a whole character
+			_, size := utf8.DecodeRuneInString(p[cont:])
+			cont += size
+			offset += size
+			runeOffset++
 		}
 	}
 
-	offset++
-
 	return words
 }
+
+// continuesCluster tells whether t belongs to the same character as prev, the
+// token right before it: emoji modifiers, variation selectors, combining
+// marks and zero width joiners left on their own by the rules, and whatever
+// follows a joiner, so that sequences such as "👍🏽" or "👩‍👩‍👧" make a
+// single token.
+func continuesCluster(prev, t string) bool {
+	last, _ := utf8.DecodeLastRuneInString(prev)
+	first, _ := utf8.DecodeRuneInString(t)
+	return last == ZERO_WIDTH_JOINER || clusterExtend(first)
+}
+
+// clusterExtend tells whether r belongs with the character before it.
+func clusterExtend(r rune) bool {
+	return r == ZERO_WIDTH_JOINER ||
+		unicode.In(r, unicode.Mn, unicode.Me, unicode.Variation_Selector) ||
+		(r >= 0x1f3fb && r <= 0x1f3ff) // skin tones
+}
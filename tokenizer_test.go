@@ -0,0 +1,183 @@
+package linguo
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// tokenizerCase is a text of the fixtures in testdata/tokenizer with its
+// expected tokens.
+type tokenizerCase struct {
+	line   int
+	text   string
+	tokens []string
+	spans  [][2]int
+}
+
+// readTokenizerCases reads a fixture file: every text, written after "> ",
+// is followed by one line per token holding its form and its rune offsets.
+func readTokenizerCases(t *testing.T, file string) []*tokenizerCase {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var cases []*tokenizerCase
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		switch {
+		case line == "" || strings.HasPrefix(line, "##"):
+		case strings.HasPrefix(line, "> "):
+			cases = append(cases, &tokenizerCase{line: n, text: line[2:]})
+		default:
+			items := strings.Fields(line)
+			if len(items) != 3 || len(cases) == 0 {
+				t.Fatalf("%s:%d: expected a form and two offsets", file, n)
+			}
+			start, err1 := strconv.Atoi(items[1])
+			end, err2 := strconv.Atoi(items[2])
+			if err1 != nil || err2 != nil {
+				t.Fatalf("%s:%d: bad offsets", file, n)
+			}
+			c := cases[len(cases)-1]
+			c.tokens = append(c.tokens, items[0])
+			c.spans = append(c.spans, [2]int{start, end})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return cases
+}
+
+func TestTokenizeUnicode(t *testing.T) {
+	tk, err := NewTokenizer("testdata/en/tokenizer.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, err := filepath.Glob("testdata/tokenizer/*.txt")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no fixtures in testdata/tokenizer: %v", err)
+	}
+
+	for _, file := range files {
+		for _, c := range readTokenizerCases(t, file) {
+			words := tk.TokenizeAt(c.text, 0, 0)
+			var got []string
+			for _, w := range words {
+				got = append(got, w.getForm())
+			}
+			if strings.Join(got, " ") != strings.Join(c.tokens, " ") {
+				t.Errorf("%s:%d: got tokens %q, want %q", file, c.line, got, c.tokens)
+				continue
+			}
+			runes := []rune(c.text)
+			for k, w := range words {
+				start, end := w.RuneSpan()
+				if start != c.spans[k][0] || end != c.spans[k][1] {
+					t.Errorf("%s:%d: token %q at runes [%d:%d], want [%d:%d]", file, c.line, w.getForm(), start, end, c.spans[k][0], c.spans[k][1])
+					continue
+				}
+				if s := c.text[w.getSpanStart():w.getSpanFinish()]; s != w.getForm() || string(runes[start:end]) != s {
+					t.Errorf("%s:%d: token %q has byte surface %q and rune surface %q", file, c.line, w.getForm(), s, string(runes[start:end]))
+				}
+			}
+		}
+	}
+}
+
+// tokenForms tokenizes text with tk and joins the forms with spaces.
+func tokenForms(tk *Tokenizer, text string) string {
+	var forms []string
+	for _, w := range tk.Tokenize(text, 0) {
+		forms = append(forms, w.getForm())
+	}
+	return strings.Join(forms, " ")
+}
+
+func newTestTokenizer(t *testing.T) *Tokenizer {
+	t.Helper()
+	tk, err := NewTokenizer("testdata/en/tokenizer.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tk
+}
+
+func TestTokenizerAddAbbreviations(t *testing.T) {
+	tk := newTestTokenizer(t)
+	text := "It weighs approx. ten kilos, see Fig. 3."
+	if got, want := tokenForms(tk, text), "It weighs approx . ten kilos , see Fig. 3 ."; got != want {
+		t.Fatalf("before: got %q, want %q", got, want)
+	}
+	tk.AddAbbreviations("Approx.")
+	if got, want := tokenForms(tk, text), "It weighs approx. ten kilos , see Fig. 3 ."; got != want {
+		t.Errorf("after: got %q, want %q", got, want)
+	}
+}
+
+func TestTokenizerAddRules(t *testing.T) {
+	tk := newTestTokenizer(t)
+	text := "Lisa's robot :-)"
+	if got, want := tokenForms(tk, text), "Lisa ' s robot : - )"; got != want {
+		t.Fatalf("before: got %q, want %q", got, want)
+	}
+
+	err := tk.AddRules(TokenizerRule{Name: "SMILEY", Pattern: `:-\)`}, TokenizerRule{Name: "BAD", Pattern: `(`})
+	if err == nil {
+		t.Fatal("invalid rule: got no error")
+	}
+	if got, want := tokenForms(tk, text), "Lisa ' s robot : - )"; got != want {
+		t.Errorf("after an invalid rule: got %q, want %q", got, want)
+	}
+
+	err = tk.AddRules(
+		TokenizerRule{Name: "POSSESSIVE", Pattern: `[[:alpha:]]+'s`},
+		TokenizerRule{Name: "SMILEY", Pattern: `:-\)`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tokenForms(tk, text), "Lisa's robot :-)"; got != want {
+		t.Errorf("after: got %q, want %q", got, want)
+	}
+}
+
+// TestTokenizerExtend loads a file on top of testdata/en/tokenizer.dat: what
+// it adds is used together with what was there.
+func TestTokenizerExtend(t *testing.T) {
+	tk := newTestTokenizer(t)
+	extra := writeTestFile(t, "tokenizer-extra.dat", "<Macros>\nSMILE [)(]\n</Macros>\n<RegExps>\nSMILEY 0 :-{SMILE}\nHASHTAG 0 #{ALPHANUM}+\n</RegExps>\n<Abbreviations>\napprox.\n</Abbreviations>\n")
+	if err := tk.Extend(extra); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		text, want string
+	}{
+		// the new rules, one of them using a macro of the base file
+		{"Robot #linguo :-(", "Robot #linguo :-("},
+		// the new abbreviation and the base one
+		{"It weighs approx. ten kilos, see Fig. 3.", "It weighs approx. ten kilos , see Fig. 3 ."},
+		// the base rules
+		{"Mr. Burns paid 3,000.50 dollars.", "Mr. Burns paid 3,000.50 dollars ."},
+	} {
+		if got := tokenForms(tk, tt.text); got != tt.want {
+			t.Errorf("%q: got %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizerExtendBadRule(t *testing.T) {
+	tk := newTestTokenizer(t)
+	extra := writeTestFile(t, "tokenizer-extra.dat", "<RegExps>\nSMILEY 0 :-\\)\nBAD 0 ([a-z]\n</RegExps>\n<Abbreviations>\napprox.\n</Abbreviations>\n")
+	checkConfigError(t, tk.Extend(extra), extra, 3, "invalid rule BAD")
+	if got, want := tokenForms(tk, "approx. :-)"), "approx . : - )"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,96 @@
+package linguo
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+)
+
+// userPattern is a line of a user map: the regular expression and the
+// analyses, as lemma and tag pairs.
+type userPattern struct {
+	re       *regexp.Regexp
+	analyses [][2]string
+}
+
+// UserMap forces the analyses of the words matching the regular expressions
+// of a file, such as product codes, ticket IDs or hashtags, before the other
+// modules of Maco see them. Every line of the file holds a regular
+// expression, which must match the whole form, followed by one or more
+// lemma and tag pairs:
+//
+//	#[a-z]+ $0 NP00000
+//	[A-Z]{2,5}-\d+ $0 NP00V00
+//	(\d+)x(\d+) ${1}x${2} Z
+//
+// Lemmas may refer to the form with $0 and to the groups of the expression
+// with $1, $2..., written ${1} when a letter or digit follows. The first
+// expression that matches wins, and the analyses of the word are locked so
+// that no other module changes them.
+type UserMap struct {
+	patterns []userPattern
+}
+
+func NewUserMap(userFile string) (*UserMap, error) {
+	this := UserMap{}
+
+	filestr, err := ioutil.ReadFile(userFile)
+	if err != nil {
+		return nil, openError(MOD_USERMAP, userFile, err)
+	}
+	lines := strings.Split(string(filestr), "\n")
+
+	for n, line := range lines {
+		if strings.HasPrefix(line, "##") {
+			continue
+		}
+		items := strings.Fields(line)
+		if len(items) == 0 {
+			continue
+		}
+		if len(items) < 3 || len(items)%2 == 0 {
+			return nil, newConfigError(MOD_USERMAP, userFile, n+1, "expected a regular expression followed by lemma and tag pairs")
+		}
+		re, err := regexp.Compile("^(?:" + items[0] + ")$")
+		if err != nil {
+			return nil, newConfigError(MOD_USERMAP, userFile, n+1, "invalid regular expression '%s': %s", items[0], err.Error())
+		}
+		p := userPattern{re: re}
+		for i := 1; i < len(items); i += 2 {
+			p.analyses = append(p.analyses, [2]string{items[i], items[i+1]})
+		}
+		this.patterns = append(this.patterns, p)
+	}
+
+	TRACE(1, "Module created successfully", MOD_USERMAP)
+
+	return &this, nil
+}
+
+func (this *UserMap) Analyze(se *Sentence) {
+	this.analyze(se)
+}
+
+func (this *UserMap) analyze(se *Sentence) {
+	for i := se.Front(); i != nil; i = i.Next() {
+		w := i.Value.(*Word)
+		form := w.getForm()
+		for _, p := range this.patterns {
+			m := p.re.FindStringSubmatchIndex(form)
+			if m == nil {
+				continue
+			}
+			TRACE(3, "   ["+form+"] matches "+p.re.String(), MOD_USERMAP)
+			for k, a := range p.analyses {
+				lemma := string(p.re.ExpandString(nil, a[0], form, m))
+				if k == 0 {
+					w.setAnalysis(NewAnalysis(lemma, a[1]))
+				} else {
+					w.addAnalysis(NewAnalysis(lemma, a[1]))
+				}
+			}
+			w.lockAnalysis()
+			break
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 func Split(s string, sep string) []string {
@@ -37,11 +38,13 @@ func WhiteSpace(c uint8) bool {
 	return false
 }
 
+// RegExHasSuffix returns the submatches of re when it matches at the start of
+// s. re is expected to be in leftmost-longest mode; Longest is not called here
+// since regexps are shared between goroutines.
 func RegExHasSuffix(re *regexp.Regexp, s string) []string {
 	if s == "" {
 		return make([]string, 0)
 	}
-	re.Longest()
 	outs := re.FindAllStringSubmatch(s, -1)
 	newOuts := make([]string, 0)
 	if len(outs) > 0 {
@@ -62,11 +65,13 @@ const (
 	UPPER_1ST
 )
 
+// Capitalization tells whether s is all uppercase, starts with an uppercase
+// letter or neither.
 func Capitalization(s string) int {
 	caps := UPPER_NONE
 	if strings.ToUpper(s) == s {
 		caps = UPPER_ALL
-	} else if strings.Title(s) == s {
+	} else if IsCapitalized(s) {
 		caps = UPPER_1ST
 	}
 
@@ -78,7 +83,10 @@ func Capitalize(form string, caps int, init bool) string {
 	if caps == UPPER_ALL {
 		cl = strings.ToUpper(cl)
 	} else if caps == UPPER_1ST && init {
-		cl = strings.Title(cl)
+		r, size := utf8.DecodeRuneInString(cl)
+		if size > 0 {
+			cl = string(unicode.ToTitle(r)) + cl[size:]
+		}
 	}
 
 	return cl
@@ -120,33 +128,49 @@ func StrArray2StrList(a []string) *list.List {
 
 func EmptyFunc(i interface{}) {}
 
+// Substr returns the l runes of s starting at rune b, or all of them up to
+// the end of s when l is -1.
 func Substr(s string, b int, l int) string {
-	ln := len(s)
+	runes := []rune(s)
+	ln := len(runes)
 	if b > ln {
 		return ""
 	}
-	if l == -1 || l >= ln {
-		return s[b:]
+	if l == -1 || b+l >= ln {
+		return string(runes[b:])
 	} else {
-		return s[b : b+l]
+		return string(runes[b : b+l])
 	}
 }
 
+// RuneIndex is strings.Index counting in runes, to be used with Substr.
+func RuneIndex(s string, substr string) int {
+	i := strings.Index(s, substr)
+	if i < 0 {
+		return i
+	}
+	return utf8.RuneCountInString(s[:i])
+}
+
+// IsCapitalized tells whether s starts with an uppercase letter or with
+// something other than a letter. Letters of scripts without case, such as
+// CJK ones, are not capitalized.
 func IsCapitalized(s string) bool {
-	s = strings.Split(s, " ")[0]
-	if strings.Title(s) == s {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 {
 		return true
 	}
-	return false
+	return !unicode.IsLetter(r) || unicode.IsUpper(r) || unicode.IsTitle(r)
 }
 
+// AllCaps tells whether s has several words and all of them are capitalized.
 func AllCaps(s string) bool {
 	items := strings.Split(s, " ")
 	if len(items) == 1 {
 		return false
 	}
 	for _, item := range items {
-		if strings.Title(item) != item {
+		if !IsCapitalized(item) {
 			return false
 		}
 	}
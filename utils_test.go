@@ -0,0 +1,29 @@
+package linguo
+
+import (
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// TestRegExHasSuffixConcurrent matches a shared regexp from several
+// goroutines, as the lexers of an engine do. Run it with -race.
+func TestRegExHasSuffixConcurrent(t *testing.T) {
+	re := regexp.MustCompile(`^[a-z]+|[0-9]+`)
+	re.Longest()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				if got := RegExHasSuffix(re, "linguo 12"); len(got) != 1 || got[0] != "linguo" {
+					t.Errorf("got %q, want [linguo]", got)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}